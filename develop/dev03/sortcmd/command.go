@@ -0,0 +1,273 @@
+package sortcmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"golang.org/x/text/encoding"
+
+	"wb-tech-l2/develop/dev03/sorter"
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// keyFlags накапливает значения повторяющегося флага -k
+type keyFlags []sorter.Key
+
+// String реализует flag.Value
+func (k *keyFlags) String() string {
+	return fmt.Sprint(len(*k), " ключ(ей)")
+}
+
+// Set реализует flag.Value: каждое вхождение -k добавляет ключ
+func (k *keyFlags) Set(value string) error {
+	key, err := sorter.ParseKey(value)
+	if err != nil {
+		return err
+	}
+	*k = append(*k, key)
+	return nil
+}
+
+// Коды завершения, как у GNU sort
+const (
+	exitOK       = textcli.ExitOK    // Успешное завершение
+	exitDisorder = textcli.ExitFalse // При -c данные не отсортированы
+	exitError    = textcli.ExitError // Ошибка в аргументах, чтении или записи
+)
+
+// Run выполняет сортировку с аргументами командной строки и возвращает код завершения
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("sort", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	// Определяем флаги
+	var keys keyFlags
+	fs.Var(&keys, "k", "Ключ сортировки в формате F1[.C1][OPTS][,F2[.C2][OPTS]], можно указать несколько раз")
+	n := fs.Bool("n", false, "Сортировка по числовому значению")
+	g := fs.Bool("g", false, "Сортировка по числовому значению с плавающей точкой (1e3, inf, nan)")
+	r := fs.Bool("r", false, "Сортировка в обратном порядке")
+	u := fs.Bool("u", false, "Не выводить повторяющиеся строки")
+	month := fs.Bool("M", false, "Сортировать по названию месяца (английскому или русскому, в том числе сокращенному)")
+	fold := fs.Bool("f", false, "Не различать регистр")
+	dictionary := fs.Bool("d", false, "Учитывать только буквы, цифры и пробелы")
+	version := fs.Bool("V", false, "Сортировать номера версий (v1.2.9 < v1.2.10)")
+	locale := fs.String("locale", sorter.LocaleFromEnv(), "Локаль сравнения строк (по умолчанию из LC_ALL, LC_COLLATE, LANG; C — побайтово)")
+	b := fs.Bool("b", false, "Игнорировать хвостовые пробелы")
+	c := fs.Bool("c", false, "Проверить отсортированность данных и сообщить о первой неупорядоченной строке")
+	quietCheck := fs.Bool("C", false, "Проверить отсортированность данных без сообщений")
+	s := fs.Bool("s", false, "Стабильная сортировка: не сравнивать строки целиком при равных ключах")
+	merge := fs.Bool("m", false, "Слить уже отсортированные файлы без сортировки")
+	h := fs.Bool("h", false, "Сортировать по числовому значению с учетом суффиксов")
+	output := fs.String("o", "", "Записать результат в файл вместо стандартного вывода")
+	bufferSize := fs.String("S", sorter.DefaultBufferSize, "Размер буфера в памяти (суффиксы b, K, M, G, T; без суффикса — K)")
+	tmpDir := fs.String("T", os.TempDir(), "Каталог для временных файлов")
+	parallel := fs.Int("parallel", runtime.NumCPU(), "Число горутин для сортировки")
+	sep := fs.String("t", "", "Разделитель полей вместо пробелов")
+	csvMode := fs.Bool("csv", false, "Разбирать строки как CSV (RFC 4180); разделитель -t, по умолчанию запятая")
+	header := fs.Bool("header", false, "Первая строка — заголовок: выводится первой, ключи -k могут ссылаться на колонки по имени")
+	encodingName := fs.String("encoding", "", "Кодировка входных данных (windows-1251, koi8-r, utf-16le...); вывод всегда в UTF-8")
+
+	if err := fs.Parse(textcli.ExpandShortFlags(fs, args)); err != nil {
+		return exitError
+	}
+
+	// fail выводит ошибку в stderr и возвращает код ошибки
+	fail := func(a ...interface{}) int {
+		return textcli.Fail(stderr, "sort", a...)
+	}
+
+	bufSize, err := sorter.ParseBufferSize(*bufferSize)
+	if err != nil {
+		return fail(err)
+	}
+	if *parallel < 1 {
+		return fail("число горутин --parallel должно быть больше нуля")
+	}
+	if textcli.IsFlagSet(fs, "t") && *sep == "" {
+		return fail("пустой разделитель полей -t")
+	}
+
+	enc, err := textcli.LookupEncoding(*encodingName)
+	if err != nil {
+		return fail(err)
+	}
+
+	// Без аргументов читаем стандартный ввод, "-" тоже означает стандартный ввод
+	files := textcli.Args(fs.Args())
+
+	st, err := sorter.New(sorter.Options{
+		Keys:    keys,
+		Numeric: *n, General: *g, Month: *month, Human: *h, Reverse: *r,
+		Fold: *fold, Dictionary: *dictionary, Version: *version,
+		Unique: *u, Stable: *s, TrimTrailing: *b, Locale: *locale,
+		Separator: *sep, CSV: *csvMode, Header: *header,
+		BufferSize: bufSize, TempDir: *tmpDir, Parallel: *parallel,
+	})
+	if err != nil {
+		return fail(err)
+	}
+
+	// Проверка отсортированности (-c, -C) не сортирует данные
+	if *c || *quietCheck {
+		if len(files) > 1 {
+			return fail("проверка -c принимает только один файл")
+		}
+		return checkInput(st, files[0], stdin, enc, stderr, *quietCheck)
+	}
+
+	// Режим -m: входные файлы уже отсортированы, их нужно только слить
+	if *merge {
+		return mergeInputs(st, files, stdin, enc, stdout, stderr, *output, *tmpDir)
+	}
+
+	// Строки читаются порциями размером не больше буфера -S. Если данные
+	// не помещаются в буфер, отсортированные порции сбрасываются во временные файлы.
+	defer st.Close()
+	for _, name := range files {
+		if err := readInput(st, name, stdin, enc); err != nil {
+			return fail(err)
+		}
+	}
+
+	// Вывод открывается только после того, как весь ввод прочитан,
+	// поэтому файл -o может совпадать с одним из входных файлов
+	if err := writeOutput(*output, stdout, st.Finish); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+// writeOutput открывает вывод (файл path или stdout) и передает его в write
+func writeOutput(path string, stdout io.Writer, write func(io.Writer) error) error {
+	if path == "" {
+		return write(stdout)
+	}
+
+	outputFile, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании файла для записи: %v", err)
+	}
+	defer outputFile.Close()
+
+	if err := write(outputFile); err != nil {
+		return err
+	}
+	if err := outputFile.Close(); err != nil {
+		return fmt.Errorf("ошибка при записи: %v", err)
+	}
+	return nil
+}
+
+// checkInput проверяет отсортированность файла name. При нарушении порядка
+// сообщает о первой неупорядоченной строке (кроме тихого режима -C)
+// и возвращает exitDisorder.
+func checkInput(st *sorter.Sorter, name string, stdin io.Reader, enc encoding.Encoding, stderr io.Writer, quiet bool) int {
+	in, err := openInput(name, stdin, enc)
+	if err != nil {
+		return textcli.Fail(stderr, "sort", err)
+	}
+	defer in.Close()
+
+	lineNum, line, err := st.Check(in)
+	if err != nil {
+		return textcli.Fail(stderr, "sort", fmt.Sprintf("ошибка при чтении %s: %v", name, err))
+	}
+	if lineNum > 0 {
+		if !quiet {
+			fmt.Fprintf(stderr, "sort: %s:%d: нарушен порядок: %s\n", name, lineNum, line)
+		}
+		return exitDisorder
+	}
+	return exitOK
+}
+
+// mergeInputs сливает уже отсортированные файлы (-m). Входной файл, совпадающий
+// с файлом вывода -o, предварительно копируется во временный файл.
+func mergeInputs(st *sorter.Sorter, files []string, stdin io.Reader, enc encoding.Encoding, stdout, stderr io.Writer, output, tmpDir string) int {
+	var sources []io.Reader
+	for _, name := range files {
+		in, err := openInput(name, stdin, enc)
+		if err == nil && output != "" && name != "-" && sameFile(name, output) {
+			in, err = copyToTemp(in, tmpDir)
+		}
+		if err != nil {
+			return textcli.Fail(stderr, "sort", err)
+		}
+		defer in.Close()
+		sources = append(sources, in)
+	}
+
+	err := writeOutput(output, stdout, func(w io.Writer) error {
+		return st.Merge(w, sources...)
+	})
+	if err != nil {
+		return textcli.Fail(stderr, "sort", err)
+	}
+	return exitOK
+}
+
+// sameFile сообщает, что два пути указывают на один и тот же существующий файл
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// tempCopy — копия входного файла, удаляемая при закрытии
+type tempCopy struct {
+	*os.File
+}
+
+// Close закрывает и удаляет временную копию
+func (t tempCopy) Close() error {
+	err := t.File.Close()
+	os.Remove(t.Name())
+	return err
+}
+
+// copyToTemp копирует содержимое in во временный файл и закрывает in
+func copyToTemp(in io.ReadCloser, tmpDir string) (io.ReadCloser, error) {
+	defer in.Close()
+
+	file, err := os.CreateTemp(tmpDir, "sort-input-*")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании временного файла: %v", err)
+	}
+	copied := tempCopy{file}
+	if _, err := io.Copy(file, in); err != nil {
+		copied.Close()
+		return nil, fmt.Errorf("ошибка при копировании во временный файл: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		copied.Close()
+		return nil, err
+	}
+	return copied, nil
+}
+
+// openInput открывает файл name для чтения ("-" — стандартный ввод)
+// и перекодирует его из кодировки enc в UTF-8
+func openInput(name string, stdin io.Reader, enc encoding.Encoding) (io.ReadCloser, error) {
+	in, err := textcli.Open(name, stdin, enc)
+	if err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+// readInput читает строки из файла name ("-" — стандартный ввод)
+func readInput(st *sorter.Sorter, name string, stdin io.Reader, enc encoding.Encoding) error {
+	in, err := openInput(name, stdin, enc)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := st.Add(in); err != nil {
+		return fmt.Errorf("ошибка при чтении %s: %v", name, err)
+	}
+	return nil
+}
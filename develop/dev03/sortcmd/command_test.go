@@ -0,0 +1,330 @@
+package sortcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunStdinToStdout(t *testing.T) {
+	for _, args := range [][]string{{"-n"}, {"-n", "-"}} {
+		var stdout, stderr bytes.Buffer
+		code := Run(args, strings.NewReader("10\n9\n100\n"), &stdout, &stderr)
+		if code != exitOK || stdout.String() != "9\n10\n100\n" {
+			t.Errorf("args %q: got code %d, output %q, stderr %q", args, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestRunMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	// Последняя строка без перевода строки не должна склеиваться со следующим файлом
+	writeFile(t, first, "c\na")
+	writeFile(t, second, "b\n")
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{first, "-", second}, strings.NewReader("d\n"), &stdout, &stderr)
+	if code != exitOK || stdout.String() != "a\nb\nc\nd\n" {
+		t.Errorf("got code %d, output %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRunOutputFileSameAsInput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.txt")
+	writeFile(t, path, "b\nc\na\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-o", path, path}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected stdout: %q", stdout.String())
+	}
+	if got := readFile(t, path); got != "a\nb\nc\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		code  int
+	}{
+		{"sorted check", []string{"-c"}, "a\nb\n", exitOK},
+		{"unsorted check", []string{"-c"}, "b\na\n", exitDisorder},
+		{"quiet check", []string{"-C", "-k2,2n"}, "a 10\nb 9\n", exitDisorder},
+		{"check with two files", []string{"-c", "-", "-"}, "", exitError},
+		{"missing file", []string{"/nonexistent/file"}, "", exitError},
+		{"bad flag", []string{"-Z"}, "", exitError},
+		{"bad key", []string{"-k", "0"}, "", exitError},
+		{"bad buffer size", []string{"-S", "10X"}, "", exitError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(test.args, strings.NewReader(test.input), &stdout, &stderr); code != test.code {
+				t.Errorf("got code %d, want %d (stderr %q)", code, test.code, stderr.String())
+			}
+			if test.code == exitError && stderr.Len() == 0 {
+				t.Error("expected error message on stderr")
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunCheckMessage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-c", "-k1,1n"}, strings.NewReader("1\n3\n2\n"), &stdout, &stderr)
+	if code != exitDisorder || stdout.Len() != 0 || !strings.Contains(stderr.String(), "-:3:") {
+		t.Errorf("got code %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestMergeMode(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	writeFile(t, first, "1 a\n3 c\n5 e\n")
+	writeFile(t, second, "2 b\n3 c\n4 d\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		input    string
+		expected string
+	}{
+		{"merge", []string{"-m", "-n", first, second}, "", "1 a\n2 b\n3 c\n3 c\n4 d\n5 e\n"},
+		{"merge unique", []string{"-m", "-n", "-u", first, second}, "", "1 a\n2 b\n3 c\n4 d\n5 e\n"},
+		{"merge stdin", []string{"-m", "-n", "-", second}, "0 z\n6 f\n", "0 z\n2 b\n3 c\n4 d\n6 f\n"},
+		// Входные данные не пересортировываются
+		{"no resort", []string{"-m"}, "b\na\n", "b\na\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+			if code != exitOK || stdout.String() != test.expected {
+				t.Errorf("got code %d, output %q, stderr %q", code, stdout.String(), stderr.String())
+			}
+		})
+	}
+
+	// Файл вывода может совпадать с одним из входных файлов
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"-m", "-n", "-o", first, first, second}, nil, &stdout, &stderr); code != exitOK {
+		t.Fatalf("got code %d, stderr %q", code, stderr.String())
+	}
+	if got := readFile(t, first); got != "1 a\n2 b\n3 c\n3 c\n4 d\n5 e\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMain(m *testing.M) {
+	// Результаты тестов не должны зависеть от локали окружения
+	os.Setenv("LC_ALL", "C")
+	os.Exit(m.Run())
+}
+
+func TestRunLocaleFlag(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--locale", "ru_RU.UTF-8", "-M", "-k2,2"}, strings.NewReader("1 марта\n2 янв\n3 February\n"), &stdout, &stderr)
+	if code != exitOK || stdout.String() != "2 янв\n3 February\n1 марта\n" {
+		t.Errorf("got code %d, output %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+
+	if code := Run([]string{"--locale", "???"}, strings.NewReader(""), &stdout, &stderr); code != exitError {
+		t.Errorf("expected error for unknown locale, got %d", code)
+	}
+}
+
+func TestRunInvalidEnvLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"LC_ALL", map[string]string{"LC_ALL": "UTF-8"}},
+		{"LANG", map[string]string{"LC_ALL": "", "LC_COLLATE": "", "LANG": "???"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			var stdout, stderr bytes.Buffer
+			code := Run(nil, strings.NewReader("b\nB\na\n"), &stdout, &stderr)
+			if code != exitOK || stdout.String() != "B\na\nb\n" || stderr.Len() != 0 {
+				t.Errorf("got code %d, output %q, stderr %q", code, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
+
+func TestFieldSeparator(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{"empty fields", []string{"-t,", "-k2,2"}, "a,,3\nb,x,1\nc,,2\n", "a,,3\nc,,2\nb,x,1\n"},
+		{"numeric field", []string{"-t", ":", "-k3,3n"}, "root:x:0\nuser:x:1000\nbin:x:2\n", "root:x:0\nbin:x:2\nuser:x:1000\n"},
+		{"multi-field key", []string{"-t;", "-k2,3"}, "1;b;2\n2;a;9\n3;b;1\n", "2;a;9\n3;b;1\n1;b;2\n"},
+		{"multi-char separator", []string{"-t", "::", "-k2,2n"}, "a::10\nb::9\n", "b::9\na::10\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(test.args, strings.NewReader(test.input), &stdout, &stderr)
+			if code != exitOK || stdout.String() != test.want {
+				t.Errorf("got code %d, output %q, stderr %q, want %q", code, stdout.String(), stderr.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestCSVSort(t *testing.T) {
+	input := "name,city,price\n" +
+		"\"Smith, John\",Moscow,300\n" +
+		"\"Doe\",\"Saint\nPetersburg\",100\n" +
+		"Ivanov,Kazan,20\n"
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"by column number", []string{"--csv", "--header", "-k3,3n"},
+			"name,city,price\nIvanov,Kazan,20\n\"Doe\",\"Saint\nPetersburg\",100\n\"Smith, John\",Moscow,300\n"},
+		{"by column name", []string{"--csv", "--header", "-k", "price:nr"},
+			"name,city,price\n\"Smith, John\",Moscow,300\n\"Doe\",\"Saint\nPetersburg\",100\nIvanov,Kazan,20\n"},
+		{"quoted field", []string{"--csv", "--header", "-k", "name"},
+			"name,city,price\n\"Doe\",\"Saint\nPetersburg\",100\nIvanov,Kazan,20\n\"Smith, John\",Moscow,300\n"},
+		{"external sort", []string{"--csv", "--header", "-k", "city", "-S", "1b"},
+			"name,city,price\nIvanov,Kazan,20\n\"Smith, John\",Moscow,300\n\"Doe\",\"Saint\nPetersburg\",100\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(append(test.args, "-T", t.TempDir()), strings.NewReader(input), &stdout, &stderr)
+			if code != exitOK || stdout.String() != test.want {
+				t.Errorf("got code %d, output %q, stderr %q, want %q", code, stdout.String(), stderr.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestHeaderMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	writeFile(t, first, "id name\n2 b\n")
+	writeFile(t, second, "id name\n1 a\n")
+
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--header", "-k", "id:n", first, second}, strings.NewReader(""), &stdout, &stderr)
+	if want := "id name\n1 a\n2 b\n"; code != exitOK || stdout.String() != want {
+		t.Errorf("got code %d, output %q, stderr %q, want %q", code, stdout.String(), stderr.String(), want)
+	}
+}
+
+func TestHeaderCheck(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-c", "--header", "-t,", "-k", "n:n"}, strings.NewReader("n\n1\n3\n2\n"), &stdout, &stderr)
+	if code != exitDisorder || !strings.Contains(stderr.String(), "-:4:") {
+		t.Errorf("got code %d, stderr %q", code, stderr.String())
+	}
+}
+
+func TestNamedColumnErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"without header", []string{"--csv", "-k", "price"}},
+		{"unknown column", []string{"--csv", "--header", "-k", "weight"}},
+		{"empty separator", []string{"-t", ""}},
+		{"header with merge", []string{"-m", "--header"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := Run(test.args, strings.NewReader("name,price\na,1\n"), &stdout, &stderr); code != exitError {
+				t.Errorf("expected exit code %d, got %d", exitError, code)
+			}
+		})
+	}
+}
+
+func TestRunFlagCombinations(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+		want  string
+	}{
+		{nil, "b\na\n", "a\nb\n"},
+		{[]string{"-r"}, "a\nb\n", "b\na\n"},
+		{[]string{"-n"}, "10\n9\n", "9\n10\n"},
+		{[]string{"-nr"}, "9\n10\n", "10\n9\n"},
+		{[]string{"-g"}, "1e2\n50\n", "50\n1e2\n"},
+		{[]string{"-M"}, "Feb\nJan\n", "Jan\nFeb\n"},
+		{[]string{"-M", "-r"}, "Jan\nFeb\n", "Feb\nJan\n"},
+		{[]string{"-h"}, "1M\n2K\n", "2K\n1M\n"},
+		{[]string{"-f"}, "b\nA\n", "A\nb\n"},
+		{[]string{"-d"}, "-b\n+a\n", "+a\n-b\n"},
+		{[]string{"-V"}, "1.10\n1.9\n", "1.9\n1.10\n"},
+		{[]string{"-u"}, "a\na\nb\n", "a\nb\n"},
+		{[]string{"-un"}, "1\n01\n2\n", "1\n2\n"},
+		{[]string{"-fu"}, "a\nA\n", "a\n"},
+		{[]string{"-b", "-u"}, "a \na\n", "a\n"},
+		{[]string{"-k2"}, "a 2\nb 1\n", "b 1\na 2\n"},
+		{[]string{"-k2,2n", "-k1,1r"}, "a 1\nb 1\nc 0\n", "c 0\nb 1\na 1\n"},
+		{[]string{"-k1.2,1.2"}, "ab\nba\n", "ba\nab\n"},
+		{[]string{"-s", "-k1,1"}, "a 2\na 1\n", "a 2\na 1\n"},
+		{[]string{"-t", ",", "-k2,2n"}, "a,2\nb,1\n", "b,1\na,2\n"},
+		{[]string{"-t,", "-k2,2", "-u"}, "a,x\nb,x\n", "a,x\n"},
+		{[]string{"--csv", "-k2,2"}, "1,\"b\"\n2,a\n", "2,a\n1,\"b\"\n"},
+		{[]string{"--csv", "-t;", "-k2,2"}, "1;\"b;c\"\n2;a\n", "2;a\n1;\"b;c\"\n"},
+		{[]string{"--csv", "-k1,1"}, "b,5\" disk\na,x\nc,y\n", "a,x\nb,5\" disk\nc,y\n"},
+		{[]string{"--csv", "-k2,2"}, "1,\"b\nx\"\n2,a\n", "2,a\n1,\"b\nx\"\n"},
+		{[]string{"--csv", "-S", "1b", "-k1,1"}, "c,\"5\" disk\"\nb,5\" disk\na,\"x\ny\"\n", "a,\"x\ny\"\nb,5\" disk\nc,\"5\" disk\"\n"},
+		{[]string{"--header", "-k", "v:n"}, "v\n10\n9\n", "v\n9\n10\n"},
+		{[]string{"--header", "--csv", "-r", "-k", "v"}, "v\na\nb\n", "v\nb\na\n"},
+		{[]string{"-S", "1b", "-n"}, "3\n1\n2\n", "1\n2\n3\n"},
+		{[]string{"-S", "1b", "-u"}, "b\na\nb\n", "a\nb\n"},
+		{[]string{"--parallel", "1", "-r"}, "a\nc\nb\n", "c\nb\na\n"},
+		{[]string{"--locale", "ru_RU.UTF-8", "-f"}, "Ель\nёж\nдом\n", "дом\nёж\nЕль\n"},
+		{[]string{"-m", "-n"}, "1\n3\n", "1\n3\n"},
+	}
+	for _, test := range tests {
+		t.Run(strings.Join(test.args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"-T", t.TempDir()}, test.args...)
+			code := Run(args, strings.NewReader(test.input), &stdout, &stderr)
+			if code != exitOK || stdout.String() != test.want {
+				t.Errorf("got code %d, output %q, stderr %q, want %q", code, stdout.String(), stderr.String(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package sorter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// LocaleFromEnv возвращает локаль сортировки из LC_ALL, LC_COLLATE или LANG.
+// Нераспознанная локаль из окружения, как в GNU sort, заменяется локалью C,
+// чтобы неверная переменная окружения не мешала сортировке.
+func LocaleFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			if _, err := newCollatorPool(value); err != nil {
+				return ""
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// newCollatorPool возвращает пул коллаторов (Unicode Collation Algorithm) для локали
+// вида "ru_RU.UTF-8" или nil, если строки сравниваются побайтово (локаль C или POSIX).
+// Коллатор хранит внутреннее состояние, поэтому каждой горутине нужен свой экземпляр.
+func newCollatorPool(locale string) (*sync.Pool, error) {
+	tag := locale
+	if i := strings.IndexAny(tag, ".@"); i >= 0 {
+		tag = tag[:i]
+	}
+	if tag == "" || tag == "C" || tag == "POSIX" {
+		return nil, nil
+	}
+
+	lang, err := language.Parse(strings.ReplaceAll(tag, "_", "-"))
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная локаль %q", locale)
+	}
+	return &sync.Pool{New: func() interface{} { return collate.New(lang) }}, nil
+}
+
+// collationKey вычисляет ключ сравнения строки для коллатора
+func collationKey(coll *collate.Collator, s string) []byte {
+	return coll.KeyFromString(&collate.Buffer{}, s)
+}
+
+// dictionaryOrder оставляет в строке только буквы, цифры и пробелы (-d)
+func dictionaryOrder(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || r == '\t' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// isDigit сообщает, является ли байт десятичной цифрой
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// compareVersions сравнивает строки как номера версий (-V): числовые части
+// сравниваются по значению, остальные — посимвольно, поэтому v1.2.9 < v1.2.10
+func compareVersions(a, b string) int {
+	for a != "" && b != "" {
+		digitA, digitB := isDigit(a[0]), isDigit(b[0])
+		// Число идет раньше текста
+		if digitA != digitB {
+			if digitA {
+				return -1
+			}
+			return 1
+		}
+
+		partA, restA := splitRun(a, digitA)
+		partB, restB := splitRun(b, digitB)
+		var result int
+		if digitA {
+			result = compareDigits(partA, partB)
+		} else {
+			result = strings.Compare(partA, partB)
+		}
+		if result != 0 {
+			return result
+		}
+		a, b = restA, restB
+	}
+	// Строка, закончившаяся раньше, меньше
+	return compareInts(len(a), len(b))
+}
+
+// splitRun отделяет от начала строки последовательность цифр (digits)
+// или последовательность нецифровых символов
+func splitRun(s string, digits bool) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareDigits сравнивает десятичные числа произвольной длины, записанные строками
+func compareDigits(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if result := compareInts(len(a), len(b)); result != 0 {
+		return result
+	}
+	return strings.Compare(a, b)
+}
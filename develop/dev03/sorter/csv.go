@@ -0,0 +1,43 @@
+package sorter
+
+import "strings"
+
+// splitCSV разбирает запись CSV на поля по RFC 4180: поле в двойных кавычках
+// может содержать разделитель и перевод строки, "" внутри кавычек означает
+// одну кавычку. Кавычки снимаются, значения полей возвращаются как есть.
+func splitCSV(record, sep string) []string {
+	if sep == "" {
+		sep = ","
+	}
+
+	var fields []string
+	var field strings.Builder
+	inQuotes, quoted := false, false
+	for i := 0; i < len(record); {
+		ch := record[i]
+		switch {
+		case inQuotes && ch == '"':
+			if i+1 < len(record) && record[i+1] == '"' {
+				field.WriteByte('"')
+				i += 2
+				continue
+			}
+			inQuotes = false
+		case inQuotes:
+			field.WriteByte(ch)
+		case strings.HasPrefix(record[i:], sep):
+			fields = append(fields, field.String())
+			field.Reset()
+			quoted = false
+			i += len(sep)
+			continue
+		case ch == '"' && field.Len() == 0 && !quoted:
+			// Кавычка в начале поля открывает значение в кавычках
+			inQuotes, quoted = true, true
+		default:
+			field.WriteByte(ch)
+		}
+		i++
+	}
+	return append(fields, field.String())
+}
@@ -0,0 +1,201 @@
+package sorter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// lineOverhead — примерный расход памяти на строку сверх ее содержимого
+// (заголовок строки в слайсе), учитывается при заполнении буфера -S
+const lineOverhead = 16
+
+// maxLineSize — максимальная длина строки, которую может прочитать сканер
+const maxLineSize = 1 << 30
+
+// DefaultBufferSize — размер буфера сортировки по умолчанию
+const DefaultBufferSize = "256M"
+
+// ParseBufferSize разбирает размер буфера в формате GNU sort -S:
+// число с суффиксом b, K, M, G или T; число без суффикса означает килобайты
+func ParseBufferSize(s string) (int64, error) {
+	if s == "" {
+		return 0, errors.New("пустой размер буфера")
+	}
+
+	numPart, multiplier := s, int64(1<<10)
+	if suffix := s[len(s)-1]; suffix < '0' || suffix > '9' {
+		numPart = s[:len(s)-1]
+		switch suffix {
+		case 'b', 'B':
+			multiplier = 1
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		case 't', 'T':
+			multiplier = 1 << 40
+		default:
+			return 0, fmt.Errorf("неверный суффикс размера буфера: %q", s)
+		}
+	}
+
+	value, err := strconv.ParseInt(numPart, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("неверный размер буфера: %q", s)
+	}
+	return value * multiplier, nil
+}
+
+// externalSorter сортирует данные, не помещающиеся в память: входные строки
+// делятся на отсортированные порции (runs), которые сбрасываются во временные
+// файлы, а затем сливаются k-путевым слиянием через кучу
+type externalSorter struct {
+	cmp     *comparator // Компаратор строк
+	tmpDir  string      // Каталог для временных файлов (-T)
+	workers int         // Число горутин для сортировки порции (--parallel)
+	runs    []string    // Пути к временным файлам с отсортированными порциями
+}
+
+// newExternalSorter создает сортировщик, использующий временные файлы в tmpDir
+func newExternalSorter(cmp *comparator, tmpDir string, workers int) *externalSorter {
+	return &externalSorter{cmp: cmp, tmpDir: tmpDir, workers: workers}
+}
+
+// spill сортирует порцию строк и записывает ее во временный файл
+func (e *externalSorter) spill(lines []string) error {
+	sortLines(lines, e.cmp, e.workers)
+
+	file, err := os.CreateTemp(e.tmpDir, "sort-run-*")
+	if err != nil {
+		return fmt.Errorf("ошибка при создании временного файла: %v", err)
+	}
+	e.runs = append(e.runs, file.Name())
+
+	writer := bufio.NewWriter(file)
+	for _, line := range lines {
+		if _, err := writer.WriteString(line + "\n"); err != nil {
+			file.Close()
+			return fmt.Errorf("ошибка при записи во временный файл: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("ошибка при записи во временный файл: %v", err)
+	}
+	return file.Close()
+}
+
+// merge сливает все порции в writer. При unique из группы строк с равными
+// ключами выводится только первая — так же, как uniqueLines в памяти.
+func (e *externalSorter) merge(writer *bufio.Writer, unique bool) error {
+	var sources []io.Reader
+	for _, path := range e.runs {
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("ошибка при открытии временного файла: %v", err)
+		}
+		defer file.Close()
+		sources = append(sources, file)
+	}
+
+	if err := mergeSorted(sources, e.cmp, writer, unique, false); err != nil {
+		return fmt.Errorf("ошибка при слиянии временных файлов: %v", err)
+	}
+	return nil
+}
+
+// cleanup удаляет временные файлы
+func (e *externalSorter) cleanup() {
+	for _, path := range e.runs {
+		os.Remove(path)
+	}
+	e.runs = nil
+}
+
+// lineScanner читает строки без ограничения на длину строки
+// и при необходимости удаляет хвостовые пробелы (-b)
+type lineScanner struct {
+	*bufio.Scanner
+	trimTrailing bool
+}
+
+// newLineScanner создает сканер строк для r. Если задан разделитель полей
+// csvSep, единицей чтения является запись CSV, которая может занимать
+// несколько строк.
+func newLineScanner(r io.Reader, trimTrailing bool, csvSep string) *lineScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	if csvSep != "" {
+		scanner.Split(textcli.ScanCSVRecords('\n', csvSep))
+	}
+	return &lineScanner{Scanner: scanner, trimTrailing: trimTrailing}
+}
+
+// Text возвращает текущую строку
+func (s *lineScanner) Text() string {
+	line := s.Scanner.Text()
+	// Если указан флаг -b, игнорируем хвостовые пробелы при чтении
+	if s.trimTrailing {
+		line = strings.TrimRight(line, " ")
+	}
+	return line
+}
+
+// lineReader накапливает строки из нескольких источников порциями:
+// как только суммарный размер строк превышает bufSize, порция передается в flush
+type lineReader struct {
+	bufSize      int64                // Размер буфера, 0 — без ограничения
+	trimTrailing bool                 // Удалять хвостовые пробелы (-b)
+	csvSep       string               // Разделитель полей записей CSV (--csv); пустой — читаются строки
+	flush        func([]string) error // Получатель заполненных порций
+	lines        []string             // Текущая порция
+	size         int64                // Размер текущей порции
+
+	header     bool               // Первая строка каждого источника — заголовок (--header)
+	headerLine string             // Заголовок первого источника
+	haveHeader bool               // Заголовок уже прочитан
+	onHeader   func(string) error // Вызывается при чтении первого заголовка
+}
+
+// read читает все строки из r. Последняя строка без перевода строки
+// не склеивается с первой строкой следующего источника.
+func (lr *lineReader) read(r io.Reader) error {
+	scanner := newLineScanner(r, lr.trimTrailing, lr.csvSep)
+	skipHeader := lr.header
+	for scanner.Scan() {
+		line := scanner.Text()
+		// Заголовок не сортируется; выводится заголовок первого источника
+		if skipHeader {
+			skipHeader = false
+			if lr.haveHeader {
+				continue
+			}
+			lr.headerLine, lr.haveHeader = line, true
+			if lr.onHeader != nil {
+				if err := lr.onHeader(line); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		lr.lines = append(lr.lines, line)
+		lr.size += int64(len(line)) + lineOverhead
+
+		if lr.bufSize > 0 && lr.size >= lr.bufSize {
+			if err := lr.flush(lr.lines); err != nil {
+				return err
+			}
+			lr.lines, lr.size = nil, 0
+		}
+	}
+	return scanner.Err()
+}
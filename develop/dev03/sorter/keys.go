@@ -0,0 +1,584 @@
+package sorter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+)
+
+// keyOptions — модификаторы сравнения, которые задаются глобально или для отдельного ключа
+type keyOptions struct {
+	numeric     bool // n: сравнение по числовому значению
+	general     bool // g: сравнение чисел с плавающей точкой (экспонента, inf, nan)
+	month       bool // M: сравнение по названию месяца
+	human       bool // h: числа с суффиксами (1K < 1M)
+	reverse     bool // r: обратный порядок
+	fold        bool // f: не различать регистр
+	dictionary  bool // d: учитывать только буквы, цифры и пробелы
+	version     bool // V: сравнение номеров версий (v1.2.9 < v1.2.10)
+	skipStartBl bool // b в начале ключа: игнорировать ведущие пробелы поля
+	skipEndBl   bool // b в конце ключа: игнорировать ведущие пробелы поля-конца
+}
+
+// isZero сообщает, что ни один модификатор не задан
+func (o keyOptions) isZero() bool {
+	return o == keyOptions{}
+}
+
+// keySpec описывает ключ сортировки в формате GNU sort: -k F1[.C1][OPTS][,F2[.C2][OPTS]].
+// Вместо номера поля можно указать имя колонки из заголовка (--header),
+// модификаторы тогда записываются после двоеточия: -k price:nr.
+type keySpec struct {
+	startField int    // Номер поля начала ключа (с 1)
+	startChar  int    // Номер символа внутри поля начала (с 1)
+	endField   int    // Номер поля конца ключа, 0 — до конца строки
+	endChar    int    // Номер последнего символа в поле конца, 0 — до конца поля
+	startName  string // Имя колонки начала ключа, если поле задано именем
+	endName    string // Имя колонки конца ключа
+	opts       keyOptions
+}
+
+// hasNames сообщает, что ключ ссылается на колонки по именам
+func (k keySpec) hasNames() bool {
+	return k.startName != "" || k.endName != ""
+}
+
+// parseKeySpec разбирает спецификацию ключа, например "2,2n", "1,1r", "3.2,3.5" или "price:n"
+func parseKeySpec(spec string) (keySpec, error) {
+	var key keySpec
+
+	startPart, endPart, hasEnd := strings.Cut(spec, ",")
+
+	field, char, name, opts, err := parseKeyPosition(startPart, true)
+	if err != nil {
+		return keySpec{}, fmt.Errorf("неверный ключ %q: %v", spec, err)
+	}
+	if field == 0 && name == "" {
+		return keySpec{}, fmt.Errorf("неверный ключ %q: номер поля должен быть больше нуля", spec)
+	}
+	if char == 0 {
+		char = 1
+	}
+	key.startField, key.startChar, key.startName = field, char, name
+	key.opts = opts
+	// Колонка, заданная именем, без явного конца означает только эту колонку
+	key.endName = name
+
+	if hasEnd {
+		field, char, name, opts, err := parseKeyPosition(endPart, false)
+		if err != nil {
+			return keySpec{}, fmt.Errorf("неверный ключ %q: %v", spec, err)
+		}
+		if field == 0 && name == "" {
+			return keySpec{}, fmt.Errorf("неверный ключ %q: номер поля должен быть больше нуля", spec)
+		}
+		key.endField, key.endChar, key.endName = field, char, name
+		// Модификаторы конца ключа объединяются с модификаторами начала,
+		// а b относится к той позиции, после которой записан
+		startBl := key.opts.skipStartBl
+		key.opts = mergeOptions(key.opts, opts)
+		key.opts.skipStartBl, key.opts.skipEndBl = startBl, opts.skipStartBl
+	}
+
+	return key, nil
+}
+
+// parseKeyPosition разбирает одну позицию ключа вида F[.C][OPTS] или NAME[:OPTS]
+func parseKeyPosition(s string, isStart bool) (field, char int, name string, opts keyOptions, err error) {
+	var optPart string
+	if s != "" && !isDigit(s[0]) {
+		// Позиция задана именем колонки
+		name, optPart, _ = strings.Cut(s, ":")
+		if name == "" {
+			return 0, 0, "", opts, errors.New("пустое имя колонки")
+		}
+	} else {
+		// Отделяем буквенные модификаторы от числовой части
+		i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+		numPart := s
+		if i >= 0 {
+			numPart, optPart = s[:i], s[i:]
+		}
+
+		fieldStr, charStr, hasChar := strings.Cut(numPart, ".")
+		if field, err = strconv.Atoi(fieldStr); err != nil {
+			return 0, 0, "", opts, errors.New("ожидается номер поля")
+		}
+		if hasChar {
+			if char, err = strconv.Atoi(charStr); err != nil {
+				return 0, 0, "", opts, errors.New("ожидается номер символа")
+			}
+			if isStart && char == 0 {
+				return 0, 0, "", opts, errors.New("номер символа должен быть больше нуля")
+			}
+		}
+	}
+
+	for _, ch := range optPart {
+		switch ch {
+		case 'n':
+			opts.numeric = true
+		case 'g':
+			opts.general = true
+		case 'M':
+			opts.month = true
+		case 'h':
+			opts.human = true
+		case 'r':
+			opts.reverse = true
+		case 'f':
+			opts.fold = true
+		case 'd':
+			opts.dictionary = true
+		case 'V':
+			opts.version = true
+		case 'b':
+			opts.skipStartBl = true
+		default:
+			return 0, 0, "", opts, fmt.Errorf("неизвестный модификатор %q", ch)
+		}
+	}
+	return field, char, name, opts, nil
+}
+
+// mergeOptions объединяет два набора модификаторов
+func mergeOptions(a, b keyOptions) keyOptions {
+	return keyOptions{
+		numeric:     a.numeric || b.numeric,
+		general:     a.general || b.general,
+		month:       a.month || b.month,
+		human:       a.human || b.human,
+		reverse:     a.reverse || b.reverse,
+		fold:        a.fold || b.fold,
+		dictionary:  a.dictionary || b.dictionary,
+		version:     a.version || b.version,
+		skipStartBl: a.skipStartBl || b.skipStartBl,
+		skipEndBl:   a.skipEndBl || b.skipEndBl,
+	}
+}
+
+// isBlank сообщает, является ли символ разделителем полей по умолчанию
+func isBlank(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+// fieldStart возвращает байтовое смещение начала поля n (с 1) в строке.
+// Как в GNU sort, поле включает предшествующие ему пробелы.
+func fieldStart(line string, n int) int {
+	pos := 0
+	for f := 1; f < n; f++ {
+		// Пропускаем пробелы и непробельные символы текущего поля
+		for pos < len(line) && isBlank(line[pos]) {
+			pos++
+		}
+		for pos < len(line) && !isBlank(line[pos]) {
+			pos++
+		}
+	}
+	return pos
+}
+
+// fieldEnd возвращает смещение конца поля, начинающегося с pos
+func fieldEnd(line string, pos int) int {
+	for pos < len(line) && isBlank(line[pos]) {
+		pos++
+	}
+	for pos < len(line) && !isBlank(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// skipBlanks пропускает пробелы начиная с pos
+func skipBlanks(line string, pos int) int {
+	for pos < len(line) && isBlank(line[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// advanceChars сдвигает pos на n символов (рун), не выходя за limit
+func advanceChars(line string, pos, n, limit int) int {
+	for ; n > 0 && pos < limit; n-- {
+		_, size := utf8.DecodeRuneInString(line[pos:])
+		pos += size
+	}
+	if pos > limit {
+		pos = limit
+	}
+	return pos
+}
+
+// extract вырезает из строки часть, соответствующую ключу
+func (k keySpec) extract(line string) string {
+	// Начало ключа
+	start := fieldStart(line, k.startField)
+	startFieldEnd := fieldEnd(line, start)
+	if k.opts.skipStartBl {
+		start = skipBlanks(line, start)
+	}
+	start = advanceChars(line, start, k.startChar-1, startFieldEnd)
+
+	// Конец ключа
+	end := len(line)
+	if k.endField > 0 {
+		endStart := fieldStart(line, k.endField)
+		end = fieldEnd(line, endStart)
+		if k.endChar > 0 {
+			if k.opts.skipEndBl {
+				endStart = skipBlanks(line, endStart)
+			}
+			end = advanceChars(line, endStart, k.endChar, end)
+		}
+	}
+
+	if end <= start {
+		return ""
+	}
+	return line[start:end]
+}
+
+// sortKey — значение ключа, вычисленное один раз для строки,
+// чтобы не разбирать строку заново при каждом сравнении
+type sortKey struct {
+	str   string  // Текст ключа (после -d и -f)
+	coll  []byte  // Ключ сравнения по правилам локали, nil — побайтовое сравнение
+	num   float64 // Значение с учетом суффикса для -h или число для -g
+	kind  int     // Вид значения для -g: не число, NaN или число
+	dec   decimal // Число для -n
+	month int     // Номер месяца для -M
+}
+
+// makeSortKey вычисляет значение ключа с учетом модификаторов.
+// coll — коллатор локали или nil для побайтового сравнения строк.
+func makeSortKey(s string, opts keyOptions, coll *collate.Collator) sortKey {
+	key := sortKey{str: s}
+	switch {
+	case opts.month:
+		key.month = monthIndex(s)
+	case opts.human:
+		key.num = humanValue(s)
+	case opts.general:
+		key.kind, key.num = parseGeneral(s)
+	case opts.numeric:
+		key.dec = parseDecimal(s)
+	case opts.version:
+		// Номера версий сравниваются посимвольно, без правил локали
+	default:
+		if opts.dictionary {
+			key.str = dictionaryOrder(key.str)
+		}
+		if opts.fold {
+			key.str = strings.ToUpper(key.str)
+		}
+		if coll != nil {
+			key.coll = collationKey(coll, key.str)
+		}
+	}
+	return key
+}
+
+// extractFields вырезает ключ из строки, уже разбитой на поля разделителем sep
+// (-t или --csv). В отличие от разбиения по пробелам, поля могут быть пустыми.
+func (k keySpec) extractFields(fields []string, sep string) string {
+	first := k.startField - 1
+	if first >= len(fields) {
+		return ""
+	}
+	startField := fields[first]
+	start := 0
+	if k.opts.skipStartBl {
+		start = skipBlanks(startField, start)
+	}
+	start = advanceChars(startField, start, k.startChar-1, len(startField))
+
+	last := len(fields) - 1
+	if k.endField > 0 && k.endField-1 < last {
+		last = k.endField - 1
+	}
+	if last < first {
+		return ""
+	}
+	endField := fields[last]
+	end := len(endField)
+	if k.endField > 0 && k.endField-1 == last && k.endChar > 0 {
+		endStart := 0
+		if k.opts.skipEndBl {
+			endStart = skipBlanks(endField, endStart)
+		}
+		end = advanceChars(endField, endStart, k.endChar, len(endField))
+	}
+
+	if first == last {
+		if end <= start {
+			return ""
+		}
+		return startField[start:end]
+	}
+
+	// Ключ из нескольких полей собирается вместе с разделителями
+	parts := append([]string{startField[start:]}, fields[first+1:last]...)
+	return strings.Join(append(parts, endField[:end]), sep)
+}
+
+// compareSortKeys сравнивает значения ключей с учетом модификаторов, возвращает -1, 0 или 1
+func compareSortKeys(a, b *sortKey, opts keyOptions) int {
+	var result int
+	switch {
+	case opts.month:
+		result = compareInts(a.month, b.month)
+	case opts.human:
+		result = compareFloats(a.num, b.num)
+	case opts.general:
+		result = compareInts(a.kind, b.kind)
+		if result == 0 {
+			result = compareFloats(a.num, b.num)
+		}
+	case opts.numeric:
+		result = compareDecimals(a.dec, b.dec)
+	case opts.version:
+		result = compareVersions(a.str, b.str)
+	case a.coll != nil && b.coll != nil:
+		result = bytes.Compare(a.coll, b.coll)
+	default:
+		result = strings.Compare(a.str, b.str)
+	}
+
+	if opts.reverse {
+		return -result
+	}
+	return result
+}
+
+// Месяцы для сортировки по названию месяца (-M): полные и сокращенные
+// английские названия и русские названия в именительном и родительном падежах.
+// Ключ сравнивается в нижнем регистре.
+var months = map[string]int{
+	"january": 1, "jan": 1, "январь": 1, "января": 1, "янв": 1,
+	"february": 2, "feb": 2, "февраль": 2, "февраля": 2, "фев": 2,
+	"march": 3, "mar": 3, "март": 3, "марта": 3, "мар": 3,
+	"april": 4, "apr": 4, "апрель": 4, "апреля": 4, "апр": 4,
+	"may": 5, "май": 5, "мая": 5,
+	"june": 6, "jun": 6, "июнь": 6, "июня": 6, "июн": 6,
+	"july": 7, "jul": 7, "июль": 7, "июля": 7, "июл": 7,
+	"august": 8, "aug": 8, "август": 8, "августа": 8, "авг": 8,
+	"september": 9, "sep": 9, "sept": 9, "сентябрь": 9, "сентября": 9, "сен": 9, "сент": 9,
+	"october": 10, "oct": 10, "октябрь": 10, "октября": 10, "окт": 10,
+	"november": 11, "nov": 11, "ноябрь": 11, "ноября": 11, "ноя": 11, "нояб": 11,
+	"december": 12, "dec": 12, "декабрь": 12, "декабря": 12, "дек": 12,
+}
+
+// monthIndex возвращает номер месяца (1-12) по первому слову ключа
+// или 0, если ключ не является месяцем. Регистр не учитывается.
+func monthIndex(s string) int {
+	s = strings.TrimLeft(s, " \t")
+	end := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end >= 0 {
+		s = s[:end]
+	}
+	return months[strings.ToLower(s)]
+}
+
+// compareInts сравнивает два целых числа
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareFloats сравнивает два вещественных числа
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// comparator сравнивает строки по цепочке ключей
+type comparator struct {
+	keys   []keySpec  // Ключи в порядке приоритета
+	global keyOptions // Глобальные модификаторы (-n, -M, -h, -r)
+	stable bool       // Не сравнивать строки целиком при равных ключах (-s)
+
+	// Пул коллаторов локали; nil — строки сравниваются побайтово
+	collators *sync.Pool
+
+	sep string // Разделитель полей (-t); пустой — поля разделяются пробелами
+	csv bool   // Поля разбираются по правилам CSV (RFC 4180) с разделителем sep
+}
+
+// newComparator создает компаратор. Ключ без собственных модификаторов
+// наследует глобальные, как в GNU sort. Без ключей вся строка — один ключ.
+func newComparator(keys []keySpec, global keyOptions) *comparator {
+	c := &comparator{global: global}
+	if len(keys) == 0 {
+		keys = []keySpec{{startField: 1, startChar: 1}}
+	}
+	for _, key := range keys {
+		if key.opts.isZero() {
+			key.opts = global
+		}
+		c.keys = append(c.keys, key)
+	}
+	return c
+}
+
+// setLocale включает сравнение строк по правилам локали (например, "ru_RU.UTF-8");
+// для локалей C и POSIX строки сравниваются побайтово
+func (c *comparator) setLocale(locale string) error {
+	collators, err := newCollatorPool(locale)
+	if err != nil {
+		return err
+	}
+	c.collators = collators
+	return nil
+}
+
+// csvSep возвращает разделитель полей для чтения записей CSV
+// или пустую строку, если вход читается по строкам
+func (c *comparator) csvSep() string {
+	if !c.csv {
+		return ""
+	}
+	return c.sep
+}
+
+// hasNamedKeys сообщает, что хотя бы один ключ ссылается на колонку по имени
+func (c *comparator) hasNamedKeys() bool {
+	for _, key := range c.keys {
+		if key.hasNames() {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveColumns заменяет имена колонок в ключах номерами полей по строке заголовка
+func (c *comparator) resolveColumns(header string) error {
+	var columns []string
+	switch {
+	case c.csv:
+		columns = splitCSV(header, c.sep)
+	case c.sep != "":
+		columns = strings.Split(header, c.sep)
+	default:
+		columns = strings.Fields(header)
+	}
+
+	index := func(name string) (int, error) {
+		for i, column := range columns {
+			if column == name {
+				return i + 1, nil
+			}
+		}
+		return 0, fmt.Errorf("колонка %q не найдена в заголовке", name)
+	}
+
+	for i := range c.keys {
+		key := &c.keys[i]
+		var err error
+		if key.startName != "" {
+			if key.startField, err = index(key.startName); err != nil {
+				return err
+			}
+		}
+		if key.endName != "" {
+			if key.endField, err = index(key.endName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// record — строка вместе с заранее вычисленными значениями ключей
+type record struct {
+	line string
+	coll []byte // Ключ сравнения всей строки по правилам локали
+	keys []sortKey
+}
+
+// newRecord вычисляет значения всех ключей строки
+func (c *comparator) newRecord(line string) record {
+	var coll *collate.Collator
+	if c.collators != nil {
+		coll = c.collators.Get().(*collate.Collator)
+		defer c.collators.Put(coll)
+	}
+
+	// При -t и --csv строка разбивается на поля один раз для всех ключей
+	var fields []string
+	switch {
+	case c.csv:
+		fields = splitCSV(line, c.sep)
+	case c.sep != "":
+		fields = strings.Split(line, c.sep)
+	}
+
+	rec := record{line: line, keys: make([]sortKey, len(c.keys))}
+	for i, key := range c.keys {
+		var value string
+		if fields != nil {
+			value = key.extractFields(fields, c.sep)
+		} else {
+			value = key.extract(line)
+		}
+		rec.keys[i] = makeSortKey(value, key.opts, coll)
+	}
+	if coll != nil {
+		rec.coll = collationKey(coll, line)
+	}
+	return rec
+}
+
+// compareRecordKeys сравнивает записи только по ключам, без последнего сравнения
+func (c *comparator) compareRecordKeys(a, b *record) int {
+	for i, key := range c.keys {
+		if result := compareSortKeys(&a.keys[i], &b.keys[i], key.opts); result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// compareRecords сравнивает записи по ключам; при равенстве всех ключей
+// строки сравниваются целиком (с учетом глобального -r), если не задан -s
+func (c *comparator) compareRecords(a, b *record) int {
+	if result := c.compareRecordKeys(a, b); result != 0 || c.stable {
+		return result
+	}
+	result := bytes.Compare(a.coll, b.coll)
+	if result == 0 {
+		result = strings.Compare(a.line, b.line)
+	}
+	if c.global.reverse {
+		return -result
+	}
+	return result
+}
+
+// compareByKeys сравнивает строки только по ключам
+func (c *comparator) compareByKeys(a, b string) int {
+	ra, rb := c.newRecord(a), c.newRecord(b)
+	return c.compareRecordKeys(&ra, &rb)
+}
+
+// compare сравнивает две строки так же, как при сортировке
+func (c *comparator) compare(a, b string) int {
+	ra, rb := c.newRecord(a), c.newRecord(b)
+	return c.compareRecords(&ra, &rb)
+}
@@ -0,0 +1,114 @@
+package sorter
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+)
+
+// mergeItem — очередная строка одного из источников в куче слияния
+type mergeItem struct {
+	rec     record
+	source  int          // Номер источника; при равенстве строк сохраняет порядок источников
+	scanner *lineScanner // Источник следующих строк
+}
+
+// mergeHeap — минимальная куча строк по компаратору сортировки
+type mergeHeap struct {
+	items []*mergeItem
+	cmp   *comparator
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	if result := h.cmp.compareRecords(&h.items[i].rec, &h.items[j].rec); result != 0 {
+		return result < 0
+	}
+	return h.items[i].source < h.items[j].source
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x interface{}) { h.items = append(h.items, x.(*mergeItem)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// mergeSorted выполняет k-путевое слияние уже отсортированных источников в writer.
+// При unique из группы строк с равными ключами выводится только первая.
+func mergeSorted(sources []io.Reader, cmp *comparator, writer *bufio.Writer, unique, trimTrailing bool) error {
+	h := &mergeHeap{cmp: cmp}
+	for i, source := range sources {
+		scanner := newLineScanner(source, trimTrailing, cmp.csvSep())
+		if scanner.Scan() {
+			h.items = append(h.items, &mergeItem{rec: cmp.newRecord(scanner.Text()), source: i, scanner: scanner})
+		} else if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	heap.Init(h)
+
+	var prev record
+	first := true
+	for h.Len() > 0 {
+		item := h.items[0]
+		if !unique || first || cmp.compareRecordKeys(&prev, &item.rec) != 0 {
+			if _, err := writer.WriteString(item.rec.line + "\n"); err != nil {
+				return fmt.Errorf("ошибка при записи: %v", err)
+			}
+		}
+		prev, first = item.rec, false
+
+		// Заменяем вершину кучи следующей строкой того же источника
+		if item.scanner.Scan() {
+			item.rec = cmp.newRecord(item.scanner.Text())
+			heap.Fix(h, 0)
+			continue
+		}
+		if err := item.scanner.Err(); err != nil {
+			return err
+		}
+		heap.Pop(h)
+	}
+	return nil
+}
+
+// checkSorted проверяет, что строки из r уже упорядочены, не сортируя их.
+// Возвращает номер (с 1) и текст первой строки, нарушающей порядок,
+// или 0, если порядок не нарушен. При unique равные по ключам строки
+// также считаются нарушением порядка. При header первая строка — заголовок:
+// она не проверяется, но по ней определяются номера именованных колонок.
+func checkSorted(r io.Reader, cmp *comparator, unique, trimTrailing, header bool) (int, string, error) {
+	scanner := newLineScanner(r, trimTrailing, cmp.csvSep())
+
+	first := 1
+	if header {
+		if scanner.Scan() {
+			if err := cmp.resolveColumns(scanner.Text()); err != nil {
+				return 0, "", err
+			}
+		}
+		first = 2
+	}
+
+	var prev record
+	for lineNum := first; scanner.Scan(); lineNum++ {
+		cur := cmp.newRecord(scanner.Text())
+		if lineNum > first {
+			result := cmp.compareRecords(&prev, &cur)
+			if unique {
+				result = cmp.compareRecordKeys(&prev, &cur)
+			}
+			if result > 0 || unique && result == 0 {
+				return lineNum, cur.line, nil
+			}
+		}
+		prev = cur
+	}
+	return 0, "", scanner.Err()
+}
@@ -0,0 +1,203 @@
+package sorter
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// decimal — десятичное число произвольной длины для -n. Число хранится
+// строками цифр, поэтому сравнение не теряет точность на длинных числах.
+type decimal struct {
+	neg      bool   // Знак минус (у нуля не ставится)
+	intPart  string // Целая часть без ведущих нулей
+	fracPart string // Дробная часть без хвостовых нулей
+}
+
+// parseDecimal разбирает число в начале ключа, как GNU sort -n: пробелы,
+// необязательный минус, цифры и дробная часть после точки. Остаток ключа
+// игнорируется, ключ без числа считается нулем.
+func parseDecimal(s string) decimal {
+	s = strings.TrimLeft(s, " \t")
+
+	var d decimal
+	if s != "" && s[0] == '-' {
+		d.neg = true
+		s = s[1:]
+	}
+
+	intPart, rest := splitRun(s, true)
+	d.intPart = strings.TrimLeft(intPart, "0")
+	if rest != "" && rest[0] == '.' {
+		fracPart, _ := splitRun(rest[1:], true)
+		d.fracPart = strings.TrimRight(fracPart, "0")
+	}
+
+	// -0 равен 0
+	if d.intPart == "" && d.fracPart == "" {
+		d.neg = false
+	}
+	return d
+}
+
+// compareDecimals сравнивает два десятичных числа
+func compareDecimals(a, b decimal) int {
+	if a.neg != b.neg {
+		if a.neg {
+			return -1
+		}
+		return 1
+	}
+
+	// Сравниваем модули: сначала длину целой части, затем цифры
+	result := compareDigits(a.intPart, b.intPart)
+	if result == 0 {
+		result = strings.Compare(a.fracPart, b.fracPart)
+	}
+	if a.neg {
+		return -result
+	}
+	return result
+}
+
+// Виды значений для -g в порядке возрастания, как в GNU sort:
+// нечисловые ключи, затем NaN, затем числа (включая бесконечности)
+const (
+	generalNotNumber = iota
+	generalNaN
+	generalNumber
+)
+
+// parseGeneral разбирает число с плавающей точкой в начале ключа для -g:
+// знак, экспонента, inf и nan. Возвращает вид значения и само значение.
+func parseGeneral(s string) (int, float64) {
+	s = strings.TrimLeft(s, " \t")
+
+	end := 0
+	if end < len(s) && (s[end] == '-' || s[end] == '+') {
+		end++
+	}
+
+	// Бесконечность и NaN записываются словами
+	lower := strings.ToLower(s[end:])
+	for _, word := range []string{"infinity", "inf", "nan"} {
+		if strings.HasPrefix(lower, word) {
+			value, _ := strconv.ParseFloat(s[:end+len(word)], 64)
+			if math.IsNaN(value) {
+				return generalNaN, 0
+			}
+			return generalNumber, value
+		}
+	}
+
+	// Мантисса: цифры с необязательной дробной частью
+	mantissa := end
+	for end < len(s) && isDigit(s[end]) {
+		end++
+	}
+	if end < len(s) && s[end] == '.' {
+		end++
+		for end < len(s) && isDigit(s[end]) {
+			end++
+		}
+	}
+	if end == mantissa || s[mantissa:end] == "." {
+		return generalNotNumber, 0
+	}
+
+	// Экспонента учитывается, только если после e есть цифры
+	if end < len(s) && (s[end] == 'e' || s[end] == 'E') {
+		exp := end + 1
+		if exp < len(s) && (s[exp] == '-' || s[exp] == '+') {
+			exp++
+		}
+		if exp < len(s) && isDigit(s[exp]) {
+			end = exp
+			for end < len(s) && isDigit(s[end]) {
+				end++
+			}
+		}
+	}
+
+	value, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil && !isRangeError(err) {
+		return generalNotNumber, 0
+	}
+	// При переполнении ParseFloat возвращает ±Inf или 0, что подходит для сравнения
+	return generalNumber, value
+}
+
+// isRangeError сообщает, что число вышло за пределы float64
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// humanValue возвращает числовое значение ключа с учетом суффикса (1K, 2MiB, 3GB, ...)
+func humanValue(s string) float64 {
+	value, suffix := extractNumericValue(s)
+	return applySuffixMultiplier(value, suffix)
+}
+
+// extractNumericValue извлекает число со знаком в начале строки (после пробелов)
+// и возвращает его вместе с остатком строки — суффиксом. Если числа нет, возвращается 0.
+func extractNumericValue(s string) (float64, string) {
+	s = strings.TrimLeft(s, " \t")
+
+	// Ищем числовую часть: необязательный знак, цифры и десятичная точка
+	end := 0
+	if end < len(s) && (s[end] == '-' || s[end] == '+') {
+		end++
+	}
+	digits := 0
+	for end < len(s) && (isDigit(s[end]) || s[end] == '.') {
+		if isDigit(s[end]) {
+			digits++
+		}
+		end++
+	}
+	if digits == 0 {
+		return 0, s
+	}
+
+	// Преобразуем числовую часть в float64
+	value, err := strconv.ParseFloat(s[:end], 64)
+	if err != nil {
+		return 0, s
+	}
+	return value, s[end:] // Остальная часть строки — это суффикс
+}
+
+// unitPrefixes — степени множителей для суффиксов размеров (K = 1, M = 2, ...)
+var unitPrefixes = map[byte]int{'K': 1, 'M': 2, 'G': 3, 'T': 4, 'P': 5, 'E': 6, 'Z': 7, 'Y': 8}
+
+// applySuffixMultiplier применяет множитель в зависимости от суффикса:
+// K, Ki, KiB — двоичные (1024), KB — десятичные (1000), B — байты.
+// Неизвестный суффикс не меняет значение.
+func applySuffixMultiplier(value float64, suffix string) float64 {
+	// Суффикс — буквы сразу после числа, например "KiB" в "1.5KiB/s"
+	end := 0
+	for end < len(suffix) && (suffix[end]|0x20) >= 'a' && (suffix[end]|0x20) <= 'z' {
+		end++
+	}
+	suffix = suffix[:end]
+	if suffix == "" || suffix == "B" || suffix == "b" {
+		return value
+	}
+
+	power, ok := unitPrefixes[suffix[0]&^0x20]
+	if !ok {
+		return value // если суффикс не найден, возвращаем исходное значение
+	}
+
+	base := 1024.0
+	switch suffix[1:] {
+	case "", "i", "iB", "ib":
+		// Двоичные единицы, как в выводе du -h и ls -h
+	case "B", "b":
+		base = 1000
+	default:
+		return value
+	}
+	return value * math.Pow(base, float64(power))
+}
@@ -0,0 +1,137 @@
+package sorter
+
+import (
+	"sort"
+	"sync"
+)
+
+// minChunkSize — минимальное число строк на горутину: на меньших порциях
+// накладные расходы на запуск горутин и слияние не окупаются
+const minChunkSize = 8192
+
+// sortLines сортирует строки в памяти, используя до workers горутин.
+// Ключи каждой строки вычисляются один раз, затем части слайса сортируются
+// параллельно и попарно сливаются.
+func sortLines(lines []string, cmp *comparator, workers int) {
+	bounds := chunkBounds(len(lines), workers)
+
+	recs := make([]record, len(lines))
+	parallelChunks(bounds, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			recs[i] = cmp.newRecord(lines[i])
+		}
+	})
+
+	sortRecords(recs, cmp, bounds)
+
+	for i := range recs {
+		lines[i] = recs[i].line
+	}
+}
+
+// chunkBounds делит n элементов на не более чем workers частей
+// размером не меньше minChunkSize; возвращает границы частей
+func chunkBounds(n, workers int) []int {
+	if workers < 1 {
+		workers = 1
+	}
+	if max := n / minChunkSize; workers > max {
+		workers = max
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	bounds := make([]int, workers+1)
+	for i := range bounds {
+		bounds[i] = n * i / workers
+	}
+	return bounds
+}
+
+// parallelChunks вызывает fn для каждой части в отдельной горутине и ждет завершения
+func parallelChunks(bounds []int, fn func(lo, hi int)) {
+	if len(bounds) == 2 {
+		fn(bounds[0], bounds[1])
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i+1 < len(bounds); i++ {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(bounds[i], bounds[i+1])
+	}
+	wg.Wait()
+}
+
+// sortRecords сортирует части recs параллельно, затем сливает соседние
+// части попарно, пока не останется одна
+func sortRecords(recs []record, cmp *comparator, bounds []int) {
+	parallelChunks(bounds, func(lo, hi int) {
+		chunk := recs[lo:hi]
+		less := func(i, j int) bool {
+			return cmp.compareRecords(&chunk[i], &chunk[j]) < 0
+		}
+		// При -s равные записи должны сохранить исходный порядок;
+		// слияние частей этот порядок не нарушает
+		if cmp.stable {
+			sort.SliceStable(chunk, less)
+		} else {
+			sort.Slice(chunk, less)
+		}
+	})
+	if len(bounds) == 2 {
+		return
+	}
+
+	src, dst := recs, make([]record, len(recs))
+	for len(bounds) > 2 {
+		var next []int
+		var wg sync.WaitGroup
+		for i := 0; i+1 < len(bounds); i += 2 {
+			lo := bounds[i]
+			next = append(next, lo)
+			// Нечетная последняя часть переносится без слияния
+			if i+2 >= len(bounds) {
+				copy(dst[lo:], src[lo:bounds[i+1]])
+				continue
+			}
+			mid, hi := bounds[i+1], bounds[i+2]
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mergeRecords(dst[lo:hi], src[lo:mid], src[mid:hi], cmp)
+			}()
+		}
+		wg.Wait()
+
+		bounds = append(next, len(recs))
+		src, dst = dst, src
+	}
+
+	// Результат последнего слияния мог оказаться во вспомогательном буфере
+	if &src[0] != &recs[0] {
+		copy(recs, src)
+	}
+}
+
+// mergeRecords сливает две отсортированные последовательности в dst.
+// При равенстве первой берется запись из левой части, что сохраняет порядок частей.
+func mergeRecords(dst, left, right []record, cmp *comparator) {
+	i, j, k := 0, 0, 0
+	for i < len(left) && j < len(right) {
+		if cmp.compareRecords(&right[j], &left[i]) < 0 {
+			dst[k] = right[j]
+			j++
+		} else {
+			dst[k] = left[i]
+			i++
+		}
+		k++
+	}
+	k += copy(dst[k:], left[i:])
+	copy(dst[k:], right[j:])
+}
@@ -0,0 +1,218 @@
+// Package sorter сортирует потоки строк по правилам GNU sort: цепочка ключей -k
+// с собственными модификаторами, числовое, месячное и версионное сравнение,
+// сравнение по локали, поля с разделителем и CSV, а для данных, не помещающихся
+// в память, — внешняя сортировка через временные файлы.
+package sorter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// Key — ключ сортировки в формате GNU sort
+type Key struct {
+	spec keySpec
+}
+
+// ParseKey разбирает спецификацию ключа, например "2,2n", "1.3,1.4" или "price:nr"
+func ParseKey(spec string) (Key, error) {
+	key, err := parseKeySpec(spec)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{spec: key}, nil
+}
+
+// Options задает параметры сортировки. Нулевое значение сортирует строки
+// целиком побайтово в памяти.
+type Options struct {
+	Keys []Key // Ключи в порядке приоритета (-k); без ключей сравнивается вся строка
+
+	// Модификаторы для ключей без собственных модификаторов
+	Numeric    bool // Числовое значение (-n)
+	General    bool // Число с плавающей точкой (-g)
+	Month      bool // Название месяца (-M)
+	Human      bool // Число с суффиксом размера (-h)
+	Reverse    bool // Обратный порядок (-r)
+	Fold       bool // Без учета регистра (-f)
+	Dictionary bool // Только буквы, цифры и пробелы (-d)
+	Version    bool // Номера версий (-V)
+
+	Unique       bool   // Одна строка из группы с равными ключами (-u)
+	Stable       bool   // Не сравнивать строки целиком при равных ключах (-s)
+	TrimTrailing bool   // Удалять хвостовые пробелы при чтении (-b)
+	Locale       string // Локаль сравнения строк; пустая, C и POSIX — побайтово
+
+	Separator string // Разделитель полей (-t); пустой — пробелы, при CSV — запятая
+	CSV       bool   // Разбирать записи как CSV (RFC 4180)
+	Header    bool   // Первая строка каждого входа — заголовок
+
+	BufferSize int64  // Размер буфера в байтах (-S); 0 — без ограничения
+	TempDir    string // Каталог для временных файлов (-T); пустой — системный
+	Parallel   int    // Число горутин сортировки; 0 — по числу процессоров
+}
+
+// Sorter сортирует, сливает и проверяет потоки строк. Входные данные
+// добавляются через Add, отсортированный результат выводит Finish.
+// Sorter не предназначен для одновременного использования из нескольких горутин.
+type Sorter struct {
+	opts    Options
+	cmp     *comparator
+	workers int
+	ext     *externalSorter
+	reader  *lineReader
+}
+
+// New создает Sorter с параметрами opts
+func New(opts Options) (*Sorter, error) {
+	keys := make([]keySpec, len(opts.Keys))
+	for i, key := range opts.Keys {
+		keys[i] = key.spec
+	}
+
+	// Глобальные модификаторы применяются к ключам без собственных модификаторов
+	cmp := newComparator(keys, keyOptions{
+		numeric: opts.Numeric, general: opts.General, month: opts.Month, human: opts.Human,
+		reverse: opts.Reverse, fold: opts.Fold, dictionary: opts.Dictionary, version: opts.Version,
+	})
+	if err := cmp.setLocale(opts.Locale); err != nil {
+		return nil, err
+	}
+	// С -u из группы равных по ключам строк остается первая из входа, как в GNU sort,
+	// поэтому строки целиком не сравниваются
+	cmp.stable = opts.Stable || opts.Unique
+	cmp.sep, cmp.csv = opts.Separator, opts.CSV
+	if opts.CSV && opts.Separator == "" {
+		cmp.sep = ","
+	}
+	// Номера именованных колонок известны только после чтения заголовка
+	if cmp.hasNamedKeys() && !opts.Header {
+		return nil, errors.New("ключи с именами колонок требуют заголовка (--header)")
+	}
+
+	workers := opts.Parallel
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	s := &Sorter{opts: opts, cmp: cmp, workers: workers}
+	s.reset()
+	return s, nil
+}
+
+// reset удаляет временные файлы и готовит Sorter к новой сортировке
+func (s *Sorter) reset() {
+	if s.ext != nil {
+		s.ext.cleanup()
+	}
+	s.ext = newExternalSorter(s.cmp, s.opts.TempDir, s.workers)
+	s.reader = &lineReader{
+		bufSize: s.opts.BufferSize, trimTrailing: s.opts.TrimTrailing, csvSep: s.cmp.csvSep(),
+		flush: s.ext.spill, header: s.opts.Header, onHeader: s.cmp.resolveColumns,
+	}
+}
+
+// Add читает все строки из r. Строки накапливаются в памяти, а при превышении
+// BufferSize сортируются порциями и сбрасываются во временные файлы.
+func (s *Sorter) Add(r io.Reader) error {
+	return s.reader.read(r)
+}
+
+// Finish сортирует все добавленные строки и записывает их в w.
+// После вызова Sorter можно использовать для новой сортировки.
+func (s *Sorter) Finish(w io.Writer) error {
+	defer s.reset()
+
+	lines := s.reader.lines
+	if len(s.ext.runs) == 0 {
+		// Все данные поместились в буфер, сортируем в памяти
+		sortLines(lines, s.cmp, s.workers)
+		if s.opts.Unique {
+			lines = uniqueLines(lines, s.cmp)
+		}
+	} else if len(lines) > 0 {
+		// Остаток тоже становится порцией для слияния
+		if err := s.ext.spill(lines); err != nil {
+			return err
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	// Заголовок выводится первым и в сортировке не участвует
+	if s.reader.haveHeader {
+		if _, err := writer.WriteString(s.reader.headerLine + "\n"); err != nil {
+			return fmt.Errorf("ошибка при записи: %v", err)
+		}
+	}
+	if len(s.ext.runs) > 0 {
+		// Слияние отсортированных порций из временных файлов
+		if err := s.ext.merge(writer, s.opts.Unique); err != nil {
+			return err
+		}
+	} else {
+		for _, line := range lines {
+			if _, err := writer.WriteString(line + "\n"); err != nil {
+				return fmt.Errorf("ошибка при записи: %v", err)
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("ошибка при записи: %v", err)
+	}
+	return nil
+}
+
+// Close удаляет временные файлы, если сортировка прервана до вызова Finish
+func (s *Sorter) Close() error {
+	s.ext.cleanup()
+	return nil
+}
+
+// Sort сортирует строки из inputs и записывает результат в w
+func (s *Sorter) Sort(w io.Writer, inputs ...io.Reader) error {
+	for _, r := range inputs {
+		if err := s.Add(r); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return s.Finish(w)
+}
+
+// Merge сливает уже отсортированные inputs в w без сортировки (-m)
+func (s *Sorter) Merge(w io.Writer, inputs ...io.Reader) error {
+	if s.opts.Header {
+		return errors.New("заголовок не поддерживается при слиянии")
+	}
+
+	writer := bufio.NewWriter(w)
+	if err := mergeSorted(inputs, s.cmp, writer, s.opts.Unique, s.opts.TrimTrailing); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("ошибка при записи: %v", err)
+	}
+	return nil
+}
+
+// Check проверяет, что строки из r уже упорядочены (-c). Возвращает номер (с 1)
+// и текст первой строки, нарушающей порядок, или 0, если порядок не нарушен.
+// При Unique равные по ключам строки также считаются нарушением порядка.
+func (s *Sorter) Check(r io.Reader) (int, string, error) {
+	return checkSorted(r, s.cmp, s.opts.Unique, s.opts.TrimTrailing, s.opts.Header)
+}
+
+// uniqueLines оставляет первую строку из каждой группы подряд идущих строк
+// с равными ключами (строки должны быть уже отсортированы)
+func uniqueLines(lines []string, cmp *comparator) []string {
+	var result []string
+	for i, line := range lines {
+		if i == 0 || cmp.compareByKeys(lines[i-1], line) != 0 {
+			result = append(result, line)
+		}
+	}
+	return result
+}
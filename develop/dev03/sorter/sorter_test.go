@@ -0,0 +1,770 @@
+package sorter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// mustKeys разбирает спецификации ключей для тестов
+func mustKeys(t *testing.T, specs ...string) []keySpec {
+	t.Helper()
+	var keys []keySpec
+	for _, spec := range specs {
+		key, err := parseKeySpec(spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", spec, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestParseKeySpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected keySpec
+	}{
+		{"2", keySpec{startField: 2, startChar: 1}},
+		{"2,2n", keySpec{startField: 2, startChar: 1, endField: 2, opts: keyOptions{numeric: true}}},
+		{"1,1r", keySpec{startField: 1, startChar: 1, endField: 1, opts: keyOptions{reverse: true}}},
+		{"3.2,3.5", keySpec{startField: 3, startChar: 2, endField: 3, endChar: 5}},
+		{"2b,2Mb", keySpec{startField: 2, startChar: 1, endField: 2,
+			opts: keyOptions{month: true, skipStartBl: true, skipEndBl: true}}},
+		{"1h", keySpec{startField: 1, startChar: 1, opts: keyOptions{human: true}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			key, err := parseKeySpec(test.spec)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key != test.expected {
+				t.Errorf("got %+v, want %+v", key, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseKeySpecErrors(t *testing.T) {
+	for _, spec := range []string{"", "0", ":n", "name:x", "1.0", "1,0", "2x", "1,2.x"} {
+		t.Run(spec, func(t *testing.T) {
+			if _, err := parseKeySpec(spec); err == nil {
+				t.Errorf("expected error for %q", spec)
+			}
+		})
+	}
+}
+
+func TestKeyExtract(t *testing.T) {
+	line := "alpha  bravo charlie"
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"1,1", "alpha"},
+		{"2,2", "  bravo"},
+		{"2b,2", "bravo"},
+		{"2", "  bravo charlie"},
+		{"3.2,3.4", "cha"},
+		{"2.2b,2.3b", "ra"},
+		{"5", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			key := mustKeys(t, test.spec)[0]
+			if got := key.extract(line); got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestMultiKeySort(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		global   keyOptions
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "numeric then reverse",
+			keys:     []string{"2,2n", "1,1r"},
+			input:    []string{"a 10", "b 2", "c 10", "d 2"},
+			expected: []string{"d 2", "b 2", "c 10", "a 10"},
+		},
+		{
+			name:     "character offsets",
+			keys:     []string{"1.3,1.4"},
+			input:    []string{"xxb2", "yya9", "zza1"},
+			expected: []string{"zza1", "yya9", "xxb2"},
+		},
+		{
+			name:     "global options inherited by plain key",
+			keys:     []string{"2,2"},
+			global:   keyOptions{numeric: true, reverse: true},
+			input:    []string{"a 1", "b 10", "c 9"},
+			expected: []string{"b 10", "c 9", "a 1"},
+		},
+		{
+			name:     "key options override globals",
+			keys:     []string{"2,2n"},
+			global:   keyOptions{reverse: true},
+			input:    []string{"a 3", "b 1", "c 2"},
+			expected: []string{"b 1", "c 2", "a 3"},
+		},
+		{
+			name:     "tie broken by whole line",
+			keys:     []string{"2,2"},
+			input:    []string{"b x", "a x", "c w"},
+			expected: []string{"c w", "a x", "b x"},
+		},
+		{
+			name:     "month and human keys",
+			keys:     []string{"1,1M", "2,2h"},
+			input:    []string{"March 1M", "January 2K", "March 3K"},
+			expected: []string{"January 2K", "March 3K", "March 1M"},
+		},
+		{
+			name:     "numeric prefix up to end of line",
+			keys:     []string{"2"},
+			global:   keyOptions{numeric: true},
+			input:    []string{"a 10 x", "b 9 y", "c -1 z"},
+			expected: []string{"c -1 z", "b 9 y", "a 10 x"},
+		},
+		{
+			name:     "no keys uses whole line",
+			global:   keyOptions{numeric: true},
+			input:    []string{"10", "9", "100"},
+			expected: []string{"9", "10", "100"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp := newComparator(mustKeys(t, test.keys...), test.global)
+			lines := append([]string(nil), test.input...)
+			sortLines(lines, cmp, 1)
+			if !reflect.DeepEqual(lines, test.expected) {
+				t.Errorf("got %q, want %q", lines, test.expected)
+			}
+		})
+	}
+}
+
+func TestUniqueByKeys(t *testing.T) {
+	cmp := newComparator(mustKeys(t, "1,1"), keyOptions{})
+	lines := []string{"a 1", "a 2", "b 1", "b 1"}
+	expected := []string{"a 1", "b 1"}
+	if got := uniqueLines(lines, cmp); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+}
+
+func TestParseBufferSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"10", 10 << 10},
+		{"512b", 512},
+		{"4K", 4 << 10},
+		{"64M", 64 << 20},
+		{"2G", 2 << 30},
+	}
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			got, err := ParseBufferSize(test.input)
+			if err != nil || got != test.expected {
+				t.Errorf("got %d (%v), want %d", got, err, test.expected)
+			}
+		})
+	}
+
+	for _, input := range []string{"", "M", "-1K", "10X", "0"} {
+		if _, err := ParseBufferSize(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+// externalSort сортирует строки через временные файлы с заданным размером буфера
+func externalSort(t *testing.T, input []string, cmp *comparator, bufSize int64, unique bool) (string, int) {
+	t.Helper()
+	ext := newExternalSorter(cmp, t.TempDir(), 2)
+	defer ext.cleanup()
+
+	reader := &lineReader{bufSize: bufSize, flush: ext.spill}
+	if err := reader.read(strings.NewReader(strings.Join(input, "\n") + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ext.spill(reader.lines); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	writer := bufio.NewWriter(&out)
+	if err := ext.merge(writer, unique); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+	return out.String(), len(ext.runs)
+}
+
+func TestExternalSortMatchesInMemory(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	input := make([]string, 2000)
+	for i := range input {
+		input[i] = fmt.Sprintf("%c %d %s", 'a'+rnd.Intn(5), rnd.Intn(100), strings.Repeat("x", rnd.Intn(3)))
+	}
+
+	tests := []struct {
+		name   string
+		keys   []string
+		global keyOptions
+		unique bool
+	}{
+		{name: "whole line"},
+		{name: "reverse numeric", keys: []string{"2,2n"}, global: keyOptions{reverse: true}},
+		{name: "multi key unique", keys: []string{"1,1", "2,2nr"}, unique: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp := newComparator(mustKeys(t, test.keys...), test.global)
+
+			lines := append([]string(nil), input...)
+			sortLines(lines, cmp, 1)
+			if test.unique {
+				lines = uniqueLines(lines, cmp)
+			}
+			expected := strings.Join(lines, "\n") + "\n"
+
+			got, runs := externalSort(t, input, cmp, 4<<10, test.unique)
+			if runs < 2 {
+				t.Fatalf("expected several runs, got %d", runs)
+			}
+			if got != expected {
+				t.Errorf("external sort output differs from in-memory sort")
+			}
+		})
+	}
+}
+
+// randomLines генерирует строки вида "слово число слово" для тестов и бенчмарков
+func randomLines(n int, seed int64) []string {
+	rnd := rand.New(rand.NewSource(seed))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%c%c %d %dK", 'a'+rnd.Intn(26), 'a'+rnd.Intn(26), rnd.Intn(1000000), rnd.Intn(4096))
+	}
+	return lines
+}
+
+func TestParallelSortMatchesSequential(t *testing.T) {
+	input := randomLines(100000, 2)
+	for _, keys := range [][]string{nil, {"2,2n"}, {"1,1r", "3,3h"}} {
+		t.Run(strings.Join(keys, " "), func(t *testing.T) {
+			cmp := newComparator(mustKeys(t, keys...), keyOptions{})
+
+			sequential := append([]string(nil), input...)
+			sortLines(sequential, cmp, 1)
+
+			for _, workers := range []int{2, 3, 8} {
+				parallel := append([]string(nil), input...)
+				sortLines(parallel, cmp, workers)
+				if !reflect.DeepEqual(parallel, sequential) {
+					t.Fatalf("parallel sort with %d workers differs from sequential", workers)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkBounds(t *testing.T) {
+	tests := []struct {
+		n, workers int
+		expected   []int
+	}{
+		{0, 4, []int{0, 0}},
+		{100, 4, []int{0, 100}},
+		{4 * minChunkSize, 4, []int{0, minChunkSize, 2 * minChunkSize, 3 * minChunkSize, 4 * minChunkSize}},
+		{3 * minChunkSize, 8, []int{0, minChunkSize, 2 * minChunkSize, 3 * minChunkSize}},
+	}
+	for _, test := range tests {
+		if got := chunkBounds(test.n, test.workers); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("chunkBounds(%d, %d) = %v, want %v", test.n, test.workers, got, test.expected)
+		}
+	}
+}
+
+// benchmarkSort сортирует миллион строк по числовому ключу с заданным числом горутин
+func benchmarkSort(b *testing.B, workers int) {
+	input := randomLines(1000000, 3)
+	cmp := newComparator([]keySpec{{startField: 2, startChar: 1, endField: 2, opts: keyOptions{numeric: true}}}, keyOptions{})
+	lines := make([]string, len(input))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(lines, input)
+		sortLines(lines, cmp, workers)
+	}
+}
+
+// BenchmarkSortRecomputeKeys — прежний подход: ключи разбираются заново при каждом сравнении
+func BenchmarkSortRecomputeKeys(b *testing.B) {
+	input := randomLines(1000000, 3)
+	cmp := newComparator([]keySpec{{startField: 2, startChar: 1, endField: 2, opts: keyOptions{numeric: true}}}, keyOptions{})
+	lines := make([]string, len(input))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		copy(lines, input)
+		sort.Slice(lines, func(i, j int) bool { return cmp.compare(lines[i], lines[j]) < 0 })
+	}
+}
+
+func BenchmarkSortSequential(b *testing.B) { benchmarkSort(b, 1) }
+
+func BenchmarkSortParallel2(b *testing.B) { benchmarkSort(b, 2) }
+
+func BenchmarkSortParallel4(b *testing.B) { benchmarkSort(b, 4) }
+
+func BenchmarkSortParallelNumCPU(b *testing.B) { benchmarkSort(b, runtime.NumCPU()) }
+
+func TestCheckReportsFirstDisorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []string
+		global  keyOptions
+		unique  bool
+		input   string
+		lineNum int
+		line    string
+	}{
+		{name: "sorted", input: "a\nb\nb\n"},
+		{name: "plain disorder", input: "a\nc\nb\nd\na\n", lineNum: 3, line: "b"},
+		{name: "by numeric key", keys: []string{"2,2n"}, input: "x 2\ny 10\nz 9\n", lineNum: 3, line: "z 9"},
+		{name: "key order respected", keys: []string{"2,2n"}, input: "b 9\na 10\n"},
+		{name: "reverse", global: keyOptions{reverse: true}, input: "c\nb\nd\n", lineNum: 3, line: "d"},
+		{name: "unique rejects equal keys", keys: []string{"1,1"}, unique: true, input: "a 1\na 2\n", lineNum: 2, line: "a 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp := newComparator(mustKeys(t, test.keys...), test.global)
+			lineNum, line, err := checkSorted(strings.NewReader(test.input), cmp, test.unique, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lineNum != test.lineNum || line != test.line {
+				t.Errorf("got %d %q, want %d %q", lineNum, line, test.lineNum, test.line)
+			}
+		})
+	}
+}
+
+func TestStableSort(t *testing.T) {
+	input := []string{"b 2", "a 1", "c 2", "a 2", "d 1"}
+
+	cmp := newComparator(mustKeys(t, "2,2n"), keyOptions{})
+	cmp.stable = true
+	lines := append([]string(nil), input...)
+	sortLines(lines, cmp, 1)
+	expected := []string{"a 1", "d 1", "b 2", "c 2", "a 2"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got %q, want %q", lines, expected)
+	}
+
+	// Внешняя сортировка с -s дает тот же порядок
+	key, _ := ParseKey("2,2n")
+	s, err := New(Options{Keys: []Key{key}, Stable: true, BufferSize: 40, TempDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := s.Sort(&out, strings.NewReader(strings.Join(input, "\n")+"\n")); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != strings.Join(expected, "\n")+"\n" {
+		t.Errorf("external: got %q", out.String())
+	}
+}
+
+func TestLocaleFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{"LC_ALL first", map[string]string{"LC_ALL": "ru_RU.UTF-8", "LANG": "en_US.UTF-8"}, "ru_RU.UTF-8"},
+		{"LANG last", map[string]string{"LC_ALL": "", "LC_COLLATE": "", "LANG": "en_US.UTF-8"}, "en_US.UTF-8"},
+		{"invalid falls back to C", map[string]string{"LC_ALL": "UTF-8"}, ""},
+		{"unset", map[string]string{"LC_ALL": "", "LC_COLLATE": "", "LANG": ""}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			if got := LocaleFromEnv(); got != test.expected {
+				t.Errorf("got %q, want %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestLocaleCollation(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []string
+		global   keyOptions
+		locale   string
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "bytes in C locale",
+			locale:   "C",
+			input:    []string{"яблоко", "Banana", "apple", "Ёж", "ель"},
+			expected: []string{"Banana", "apple", "Ёж", "ель", "яблоко"},
+		},
+		{
+			name:     "russian collation",
+			locale:   "ru_RU.UTF-8",
+			input:    []string{"яблоко", "Ёж", "ель", "Дом", "арбуз"},
+			expected: []string{"арбуз", "Дом", "Ёж", "ель", "яблоко"},
+		},
+		{
+			name:     "mixed latin and cyrillic",
+			locale:   "en_US.UTF-8",
+			input:    []string{"banana", "Apple", "арбуз", "cherry"},
+			expected: []string{"Apple", "banana", "cherry", "арбуз"},
+		},
+		{
+			name:     "fold case in C locale",
+			locale:   "C",
+			global:   keyOptions{fold: true},
+			input:    []string{"b", "A", "a", "B"},
+			expected: []string{"A", "a", "B", "b"},
+		},
+		{
+			name:     "fold cyrillic",
+			locale:   "C",
+			keys:     []string{"1,1f"},
+			input:    []string{"Бык", "аист", "Аист", "бобр"},
+			expected: []string{"Аист", "аист", "бобр", "Бык"},
+		},
+		{
+			name:     "dictionary order",
+			locale:   "C",
+			global:   keyOptions{dictionary: true},
+			input:    []string{"#c", "b-", "(a)"},
+			expected: []string{"(a)", "b-", "#c"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp := newComparator(mustKeys(t, test.keys...), test.global)
+			if err := cmp.setLocale(test.locale); err != nil {
+				t.Fatal(err)
+			}
+			lines := append([]string(nil), test.input...)
+			sortLines(lines, cmp, 2)
+			if !reflect.DeepEqual(lines, test.expected) {
+				t.Errorf("got %q, want %q", lines, test.expected)
+			}
+		})
+	}
+}
+
+func TestMonthNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"January", 1},
+		{"  feb", 2},
+		{"MAR", 3},
+		{"Sept", 9},
+		{"январь", 1},
+		{"Марта", 3},
+		{"янв.", 1},
+		{"15 мая", 0},
+		{"мая 2024", 5},
+		{"дек", 12},
+		{"smarch", 0},
+	}
+	for _, test := range tests {
+		if got := monthIndex(test.input); got != test.expected {
+			t.Errorf("monthIndex(%q) = %d, want %d", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestVersionSort(t *testing.T) {
+	cmp := newComparator(nil, keyOptions{version: true})
+	lines := []string{"v1.2.10", "v1.10", "v1.2.9", "v1.2", "v1.2a", "v0.9", "v1.02.3"}
+	sortLines(lines, cmp, 1)
+	expected := []string{"v0.9", "v1.2", "v1.02.3", "v1.2.9", "v1.2.10", "v1.2a", "v1.10"}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got %q, want %q", lines, expected)
+	}
+}
+
+func TestNumericSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   keyOptions
+		input    []string
+		expected []string
+	}{
+		{
+			name:     "negative and fractional",
+			global:   keyOptions{numeric: true},
+			input:    []string{"10", "-2.5", "3.14", "-10", "0.5", "3"},
+			expected: []string{"-10", "-2.5", "0.5", "3", "3.14", "10"},
+		},
+		{
+			name:     "non-numeric keys equal zero",
+			global:   keyOptions{numeric: true},
+			input:    []string{"1", "abc", "-1", "", "xyz"},
+			expected: []string{"-1", "", "abc", "xyz", "1"},
+		},
+		{
+			name:     "long numbers keep precision",
+			global:   keyOptions{numeric: true},
+			input:    []string{"123456789012345678901", "123456789012345678900", "99"},
+			expected: []string{"99", "123456789012345678900", "123456789012345678901"},
+		},
+		{
+			name:     "exponent ignored by -n",
+			global:   keyOptions{numeric: true},
+			input:    []string{"2e3", "100"},
+			expected: []string{"2e3", "100"},
+		},
+		{
+			name:     "general numeric",
+			global:   keyOptions{general: true},
+			input:    []string{"2e3", "100", "-inf", "nan", "abc", "+1.5e-2", "inf", "-0.5"},
+			expected: []string{"abc", "nan", "-inf", "-0.5", "+1.5e-2", "100", "2e3", "inf"},
+		},
+		{
+			name:     "human signs and units",
+			global:   keyOptions{human: true},
+			input:    []string{"1K", "-2M", "1000", "1KB", "1.5G", "1500M", "1KiB", "0", "2T"},
+			expected: []string{"-2M", "0", "1000", "1KB", "1K", "1KiB", "1500M", "1.5G", "2T"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp := newComparator(nil, test.global)
+			lines := append([]string(nil), test.input...)
+			sortLines(lines, cmp, 1)
+			if !reflect.DeepEqual(lines, test.expected) {
+				t.Errorf("got %q, want %q", lines, test.expected)
+			}
+		})
+	}
+}
+
+func TestHumanValue(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"512", 512},
+		{"  -1.5K", -1536},
+		{"2KiB", 2048},
+		{"2kB", 2000},
+		{"3MB", 3e6},
+		{"1GiB/s", 1 << 30},
+		{"10 apples", 10},
+		{"7X", 7},
+		{"abc", 0},
+	}
+	for _, test := range tests {
+		if got := humanValue(test.input); got != test.expected {
+			t.Errorf("humanValue(%q) = %v, want %v", test.input, got, test.expected)
+		}
+	}
+}
+
+func TestSplitCSV(t *testing.T) {
+	tests := []struct {
+		record string
+		sep    string
+		want   []string
+	}{
+		{"a,b,c", ",", []string{"a", "b", "c"}},
+		{`"a,b",c`, ",", []string{"a,b", "c"}},
+		{`"say ""hi""",x`, ",", []string{`say "hi"`, "x"}},
+		{"\"line1\nline2\",y", ",", []string{"line1\nline2", "y"}},
+		{",,", ",", []string{"", "", ""}},
+		{`a;"b;c"`, ";", []string{"a", "b;c"}},
+	}
+	for _, test := range tests {
+		if got := splitCSV(test.record, test.sep); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitCSV(%q, %q) = %q, want %q", test.record, test.sep, got, test.want)
+		}
+	}
+}
+
+// mustParseKeys разбирает спецификации ключей в значения Key
+func mustParseKeys(t *testing.T, specs ...string) []Key {
+	t.Helper()
+	var keys []Key
+	for _, spec := range specs {
+		key, err := ParseKey(spec)
+		if err != nil {
+			t.Fatalf("parse %q: %v", spec, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestSorterOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []string
+		opts  Options
+		input string
+		want  string
+	}{
+		{"zero options", nil, Options{}, "b\na\nc\n", "a\nb\nc\n"},
+		{"numeric", nil, Options{Numeric: true}, "10\n9\n-1\n", "-1\n9\n10\n"},
+		{"numeric reverse", nil, Options{Numeric: true, Reverse: true}, "10\n9\n-1\n", "10\n9\n-1\n"},
+		{"general", nil, Options{General: true}, "1e3\n2\ninf\n", "2\n1e3\ninf\n"},
+		{"month", nil, Options{Month: true}, "Mar\nJan\nfeb\n", "Jan\nfeb\nMar\n"},
+		{"human", nil, Options{Human: true}, "1G\n10K\n2M\n", "10K\n2M\n1G\n"},
+		{"fold", nil, Options{Fold: true}, "b\nB\na\n", "a\nB\nb\n"},
+		{"dictionary", nil, Options{Dictionary: true}, "-c\n+b\n(a\n", "(a\n+b\n-c\n"},
+		{"version", nil, Options{Version: true}, "v1.10\nv1.9\n", "v1.9\nv1.10\n"},
+		{"unique", nil, Options{Unique: true}, "b\na\nb\na\n", "a\nb\n"},
+		{"unique keeps first of equal keys", nil, Options{Unique: true, Fold: true}, "b\nB\na\nA\n", "a\nb\n"},
+		{"unique keeps first by key", []string{"1,1"}, Options{Unique: true}, "x 2\ny 1\nx 1\n", "x 2\ny 1\n"},
+		{"external unique keeps first", nil, Options{BufferSize: 10, Unique: true, Fold: true}, "b\nB\na\nA\nc\nC\nb\n", "a\nb\nc\n"},
+		{"unique numeric", nil, Options{Unique: true, Numeric: true}, "01\n1\n2\n", "01\n2\n"},
+		{"trim trailing", nil, Options{TrimTrailing: true, Unique: true}, "a  \na\n", "a\n"},
+		{"locale", nil, Options{Locale: "ru_RU.UTF-8"}, "ель\nЁж\nДом\n", "Дом\nЁж\nель\n"},
+		{"key with own options", []string{"2,2nr"}, Options{}, "a 1\nb 3\nc 2\n", "b 3\nc 2\na 1\n"},
+		{"key inherits globals", []string{"2,2"}, Options{Numeric: true}, "a 10\nb 9\n", "b 9\na 10\n"},
+		{"several keys", []string{"1,1", "2,2n"}, Options{}, "b 2\na 10\na 9\n", "a 9\na 10\nb 2\n"},
+		{"stable", []string{"1,1"}, Options{Stable: true}, "a 2\nb 1\na 1\n", "a 2\na 1\nb 1\n"},
+		{"stable reverse", []string{"1,1"}, Options{Stable: true, Reverse: true}, "a 2\nb 1\na 1\n", "b 1\na 2\na 1\n"},
+		{"separator", []string{"2,2n"}, Options{Separator: ":"}, "x:10\ny:9\n", "y:9\nx:10\n"},
+		{"csv", []string{"2,2"}, Options{CSV: true}, "1,\"b,x\"\n2,a\n", "2,a\n1,\"b,x\"\n"},
+		{"csv header by name", []string{"n:n"}, Options{CSV: true, Header: true}, "n\n2\n10\n1\n", "n\n1\n2\n10\n"},
+		{"csv stray quote", []string{"1,1"}, Options{CSV: true}, "b,5\" disk\na,x\nc,y\n", "a,x\nb,5\" disk\nc,y\n"},
+		{"csv quoted newline", []string{"2,2"}, Options{CSV: true}, "1,\"b\nx\"\n2,a\n", "2,a\n1,\"b\nx\"\n"},
+		{"csv quoted newline with separator", []string{"2,2"}, Options{CSV: true, Separator: ";"}, "1;\"b\n;x\"\n2;a\n", "2;a\n1;\"b\n;x\"\n"},
+		{"header whitespace", []string{"2,2"}, Options{Header: true}, "id v\n1 b\n2 a\n", "id v\n2 a\n1 b\n"},
+		{"external", nil, Options{BufferSize: 20, Numeric: true}, "5\n3\n9\n1\n7\n2\n8\n", "1\n2\n3\n5\n7\n8\n9\n"},
+		{"external unique", nil, Options{BufferSize: 20, Unique: true}, "b\na\nb\nc\na\nc\n", "a\nb\nc\n"},
+		{"external csv header", []string{"v"}, Options{BufferSize: 20, CSV: true, Header: true},
+			"v\n\"c\nc\"\nb\na\n", "v\na\nb\n\"c\nc\"\n"},
+		{"parallel", nil, Options{Parallel: 4}, "c\nb\na\n", "a\nb\nc\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.Keys = mustParseKeys(t, test.keys...)
+			opts.TempDir = t.TempDir()
+			s, err := New(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			if err := s.Sort(&out, strings.NewReader(test.input)); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+		})
+	}
+}
+
+func TestSorterReuse(t *testing.T) {
+	s, err := New(Options{Numeric: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range []string{"3\n1\n2\n", "20\n10\n"} {
+		if err := s.Add(strings.NewReader(input)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	if err := s.Finish(&out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n2\n3\n10\n20\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	// После Finish сортировка начинается заново
+	out.Reset()
+	if err := s.Sort(&out, strings.NewReader("5\n4\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "4\n5\n"; out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
+
+func TestSorterMergeAndCheck(t *testing.T) {
+	s, err := New(Options{Numeric: true, Unique: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := s.Merge(&out, strings.NewReader("1\n3\n"), strings.NewReader("2\n3\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "1\n2\n3\n"; out.String() != want {
+		t.Errorf("merge: got %q, want %q", out.String(), want)
+	}
+
+	lineNum, line, err := s.Check(strings.NewReader("1\n2\n2\n"))
+	if err != nil || lineNum != 3 || line != "2" {
+		t.Errorf("check: got %d %q %v", lineNum, line, err)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []string
+		opts Options
+	}{
+		{"unknown locale", nil, Options{Locale: "???"}},
+		{"named key without header", []string{"price"}, Options{CSV: true}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := test.opts
+			opts.Keys = mustParseKeys(t, test.keys...)
+			if _, err := New(opts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	s, err := New(Options{Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Merge(&bytes.Buffer{}, strings.NewReader("a\n")); err == nil {
+		t.Error("expected error for merge with header")
+	}
+}
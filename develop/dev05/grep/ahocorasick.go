@@ -0,0 +1,204 @@
+package grep
+
+import "sort"
+
+// ahoCorasick ищет множество фиксированных строк за один проход по строке:
+// шаблоны собраны в бор с суффиксными ссылками, поэтому время поиска
+// не зависит от числа шаблонов
+type ahoCorasick struct {
+	opts     MatchOptions
+	nodes    []acNode
+	lines    map[string]bool // Шаблоны для сравнения со всей строкой (Line)
+	hasEmpty bool            // Среди шаблонов есть пустая строка
+	fold     bool            // Не различать регистр ASCII
+}
+
+// acNode — вершина бора
+type acNode struct {
+	next   map[byte]int32 // Переходы по байтам
+	fail   int32          // Вершина самого длинного собственного суффикса в боре
+	output int32          // Ближайшая по суффиксным ссылкам вершина-шаблон, -1 — нет
+	length int            // Длина шаблона, оканчивающегося в вершине; 0 — не шаблон
+}
+
+// NewAhoCorasick строит автомат Ахо–Корасик для фиксированных строк.
+// Без учета регистра автомат сравнивает байты со сложением регистра ASCII;
+// если среди шаблонов есть символы вне ASCII, используется регулярное выражение.
+func NewAhoCorasick(patterns []string, opts MatchOptions) Matcher {
+	if opts.IgnoreCase && !isASCII(patterns) {
+		return NewFixed(patterns, opts)
+	}
+
+	m := &ahoCorasick{opts: opts, fold: opts.IgnoreCase, lines: make(map[string]bool)}
+	m.nodes = []acNode{{next: make(map[byte]int32), output: -1}}
+	for _, pattern := range patterns {
+		pattern = m.foldString(pattern)
+		m.lines[pattern] = true
+		if pattern == "" {
+			m.hasEmpty = true
+			continue
+		}
+		m.insert(pattern)
+	}
+	m.link()
+	return m
+}
+
+// isASCII сообщает, что все шаблоны состоят из символов ASCII
+func isASCII(patterns []string) bool {
+	for _, pattern := range patterns {
+		for i := 0; i < len(pattern); i++ {
+			if pattern[i] >= 0x80 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// foldByte приводит букву ASCII к нижнему регистру, если регистр не учитывается
+func (m *ahoCorasick) foldByte(c byte) byte {
+	if m.fold && 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// foldString приводит строку к нижнему регистру ASCII, если регистр не учитывается
+func (m *ahoCorasick) foldString(s string) string {
+	if !m.fold {
+		return s
+	}
+	b := []byte(s)
+	for i := range b {
+		b[i] = m.foldByte(b[i])
+	}
+	return string(b)
+}
+
+// insert добавляет шаблон в бор
+func (m *ahoCorasick) insert(pattern string) {
+	node := int32(0)
+	for i := 0; i < len(pattern); i++ {
+		next, ok := m.nodes[node].next[pattern[i]]
+		if !ok {
+			next = int32(len(m.nodes))
+			m.nodes = append(m.nodes, acNode{next: make(map[byte]int32), output: -1})
+			m.nodes[node].next[pattern[i]] = next
+		}
+		node = next
+	}
+	m.nodes[node].length = len(pattern)
+}
+
+// link вычисляет суффиксные ссылки обходом бора в ширину
+func (m *ahoCorasick) link() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for c, child := range m.nodes[node].next {
+			queue = append(queue, child)
+			fail := m.step(m.nodes[node].fail, c)
+			m.nodes[child].fail = fail
+			if m.nodes[fail].length > 0 {
+				m.nodes[child].output = fail
+			} else {
+				m.nodes[child].output = m.nodes[fail].output
+			}
+		}
+	}
+}
+
+// step выполняет переход автомата из node по байту c
+func (m *ahoCorasick) step(node int32, c byte) int32 {
+	for {
+		if next, ok := m.nodes[node].next[c]; ok {
+			return next
+		}
+		if node == 0 {
+			return 0
+		}
+		node = m.nodes[node].fail
+	}
+}
+
+// each вызывает fn для каждого непустого вхождения шаблонов в порядке
+// их окончания; fn возвращает false, чтобы прекратить поиск
+func (m *ahoCorasick) each(line string, fn func(start, end int) bool) {
+	node := int32(0)
+	for i := 0; i < len(line); i++ {
+		node = m.step(node, m.foldByte(line[i]))
+		for out := node; out >= 0; out = m.nodes[out].output {
+			if length := m.nodes[out].length; length > 0 && !fn(i+1-length, i+1) {
+				return
+			}
+		}
+	}
+}
+
+// Match реализует Matcher
+func (m *ahoCorasick) Match(line string) bool {
+	if m.opts.Line {
+		return m.lines[m.foldString(line)]
+	}
+	if m.hasEmpty {
+		if !m.opts.Word {
+			return true
+		}
+		// Пустое слово — позиция, вокруг которой нет символов слова
+		for i := 0; i <= len(line); i = nextRune(line, i) {
+			if isWordMatch(line, i, i) {
+				return true
+			}
+		}
+	}
+
+	found := false
+	m.each(line, func(start, end int) bool {
+		found = !m.opts.Word || isWordMatch(line, start, end)
+		return !found
+	})
+	return found
+}
+
+// FindAll реализует Matcher. Из вхождений с одинаковым началом выбирается
+// самое длинное, как и в NewFixed.
+func (m *ahoCorasick) FindAll(line string) [][]int {
+	if m.opts.Line {
+		if line != "" && m.lines[m.foldString(line)] {
+			return [][]int{{0, len(line)}}
+		}
+		return nil
+	}
+
+	var found [][]int
+	m.each(line, func(start, end int) bool {
+		found = append(found, []int{start, end})
+		return true
+	})
+	sort.Slice(found, func(i, j int) bool {
+		if found[i][0] != found[j][0] {
+			return found[i][0] < found[j][0]
+		}
+		return found[i][1] > found[j][1]
+	})
+
+	var result [][]int
+	pos := 0
+	for _, loc := range found {
+		if loc[0] < pos {
+			continue
+		}
+		if m.opts.Word && !isWordMatch(line, loc[0], loc[1]) {
+			// Более короткое вхождение с тем же началом может оказаться словом
+			continue
+		}
+		result = append(result, loc)
+		pos = loc[1]
+	}
+	return result
+}
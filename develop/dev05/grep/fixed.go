@@ -0,0 +1,123 @@
+package grep
+
+import "strings"
+
+// fixedMatcher ищет фиксированные строки как подстроки
+type fixedMatcher struct {
+	opts     MatchOptions
+	patterns []string
+}
+
+// NewFixed создает сопоставитель фиксированных строк. Для каждой строки
+// выполняется отдельный поиск подстроки, поэтому для множества шаблонов лучше
+// подходит NewAhoCorasick. Без учета регистра шаблоны ищутся регулярным
+// выражением: при смене регистра может меняться длина строки в байтах.
+func NewFixed(patterns []string, opts MatchOptions) Matcher {
+	if opts.IgnoreCase && len(patterns) > 0 {
+		opts.Fixed = true
+		// Экранированные шаблоны всегда компилируются
+		m, _ := NewRegex(patterns, opts)
+		return m
+	}
+	return &fixedMatcher{opts: opts, patterns: patterns}
+}
+
+// Match реализует Matcher
+func (m *fixedMatcher) Match(line string) bool {
+	for _, pattern := range m.patterns {
+		if m.matchPattern(line, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern ищет фиксированную строку pattern в line с учетом Line и Word
+func (m *fixedMatcher) matchPattern(line, pattern string) bool {
+	if m.opts.Line {
+		return line == pattern
+	}
+	if !m.opts.Word {
+		return strings.Contains(line, pattern)
+	}
+
+	// Вхождения могут перекрываться, поэтому после неудачи сдвигаемся на один символ
+	for start := 0; start <= len(line); {
+		i := strings.Index(line[start:], pattern)
+		if i < 0 {
+			return false
+		}
+		i += start
+		if isWordMatch(line, i, i+len(pattern)) {
+			return true
+		}
+		start = nextRune(line, i)
+	}
+	return false
+}
+
+// FindAll реализует Matcher. Из вхождений с одинаковым началом выбирается
+// самое длинное.
+func (m *fixedMatcher) FindAll(line string) [][]int {
+	if m.opts.Line {
+		for _, pattern := range m.patterns {
+			if line == pattern && line != "" {
+				return [][]int{{0, len(line)}}
+			}
+		}
+		return nil
+	}
+
+	var result [][]int
+	for pos := 0; pos < len(line); {
+		start, end := m.find(line, pos)
+		if start < 0 {
+			break
+		}
+		if m.opts.Word {
+			// Самое длинное вхождение может не быть словом, а более короткое —
+			// быть; если слова нет, ищем со следующего символа
+			if end = m.longestWord(line, start); end < 0 {
+				pos = nextRune(line, start)
+				continue
+			}
+		}
+		result = append(result, []int{start, end})
+		pos = end
+	}
+	return result
+}
+
+// find ищет ближайшее к pos вхождение любой из непустых строк
+func (m *fixedMatcher) find(line string, pos int) (int, int) {
+	start, end := -1, -1
+	for _, pattern := range m.patterns {
+		if pattern == "" {
+			continue
+		}
+		i := strings.Index(line[pos:], pattern)
+		if i < 0 {
+			continue
+		}
+		i += pos
+		if start < 0 || i < start || i == start && i+len(pattern) > end {
+			start, end = i, i+len(pattern)
+		}
+	}
+	return start, end
+}
+
+// longestWord возвращает конец самого длинного вхождения, которое начинается
+// в start и является отдельным словом, или -1
+func (m *fixedMatcher) longestWord(line string, start int) int {
+	end := -1
+	for _, pattern := range m.patterns {
+		if pattern == "" || !strings.HasPrefix(line[start:], pattern) {
+			continue
+		}
+		if e := start + len(pattern); e > end && isWordMatch(line, start, e) {
+			end = e
+		}
+	}
+	return end
+}
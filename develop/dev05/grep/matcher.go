@@ -0,0 +1,74 @@
+// Package grep — движок поиска строк утилиты grep: сопоставители шаблонов
+// (фиксированные строки, регулярные выражения, Ахо–Корасик для множества строк)
+// и потоковый поиск с контекстом, передающий найденные строки в Sink.
+package grep
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Matcher проверяет строки на совпадение с шаблонами
+type Matcher interface {
+	// Match сообщает, что строка совпадает хотя бы с одним шаблоном
+	Match(line string) bool
+	// FindAll возвращает границы всех непересекающихся непустых совпадений
+	// в строке слева направо
+	FindAll(line string) [][]int
+}
+
+// MatchOptions — параметры сопоставления строк с шаблонами
+type MatchOptions struct {
+	Fixed      bool // Шаблоны — фиксированные строки (-F)
+	Extended   bool // Расширенные регулярные выражения POSIX (-E)
+	IgnoreCase bool // Не различать регистр (-i)
+	Word       bool // Совпадение должно быть целым словом (-w)
+	Line       bool // Совпадение должно занимать всю строку (-x)
+}
+
+// New выбирает сопоставитель для шаблонов: несколько фиксированных строк ищутся
+// автоматом Ахо–Корасик, одна — поиском подстроки, остальные шаблоны —
+// регулярными выражениями. Без шаблонов ни одна строка не совпадает.
+func New(patterns []string, opts MatchOptions) (Matcher, error) {
+	switch {
+	case len(patterns) == 0:
+		return NewFixed(nil, opts), nil
+	case opts.Fixed && len(patterns) > 1:
+		return NewAhoCorasick(patterns, opts), nil
+	case opts.Fixed:
+		return NewFixed(patterns, opts), nil
+	}
+	return NewRegex(patterns, opts)
+}
+
+// isWordMatch сообщает, что совпадение line[start:end] не является частью
+// более длинного слова: до и после него нет букв, цифр и подчеркивания (-w)
+func isWordMatch(line string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(line[:start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(line) {
+		r, _ := utf8.DecodeRuneInString(line[end:])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordRune сообщает, что символ может входить в слово
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// nextRune возвращает позицию следующего символа после line[i]
+func nextRune(line string, i int) int {
+	if i >= len(line) {
+		return i + 1
+	}
+	_, size := utf8.DecodeRuneInString(line[i:])
+	return i + max(size, 1)
+}
@@ -0,0 +1,207 @@
+package grep
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     MatchOptions
+		line     string
+		want     bool
+	}{
+		{"regex", []string{`ab+c`}, MatchOptions{}, "xabbbcx", true},
+		{"regex no match", []string{`ab+c`}, MatchOptions{}, "ac", false},
+		{"regex character class", []string{`\d{3}-\d{2}`}, MatchOptions{}, "tel 555-12", true},
+		{"extended", []string{`(foo|bar)+`}, MatchOptions{Extended: true}, "xbarfoo", true},
+		{"fixed substring", []string{"a.c"}, MatchOptions{Fixed: true}, "xxa.cxx", true},
+		{"fixed is not regex", []string{"a.c"}, MatchOptions{Fixed: true}, "abc", false},
+		{"ignore case regex", []string{"HELLO"}, MatchOptions{IgnoreCase: true}, "say hello", true},
+		{"ignore case fixed", []string{"Привет"}, MatchOptions{Fixed: true, IgnoreCase: true}, "ПРИВЕТ, мир", true},
+		{"ignore case several fixed", []string{"foo", "Bar"}, MatchOptions{Fixed: true, IgnoreCase: true}, "xBARx", true},
+		{"ignore case several fixed non-ASCII", []string{"foo", "ёж"}, MatchOptions{Fixed: true, IgnoreCase: true}, "ЁЖ", true},
+		{"word", []string{"cat"}, MatchOptions{Word: true}, "a cat here", true},
+		{"word inside longer word", []string{"cat"}, MatchOptions{Word: true}, "concatenate", false},
+		{"word later occurrence", []string{"cat"}, MatchOptions{Word: true}, "cats and cat", true},
+		{"word fixed", []string{"cat"}, MatchOptions{Fixed: true, Word: true}, "cats, cat_, cat.", true},
+		{"word fixed only parts", []string{"cat"}, MatchOptions{Fixed: true, Word: true}, "cats cat_", false},
+		{"word several fixed shorter pattern", []string{"cats", "cat"}, MatchOptions{Fixed: true, Word: true}, "catsup cat", true},
+		{"word cyrillic", []string{"кот"}, MatchOptions{Word: true}, "котенок", false},
+		{"word shorter alternative", []string{`ab|abc`}, MatchOptions{Word: true}, "abc", true},
+		{"word shorter match at same start", []string{`a-b|a`}, MatchOptions{Word: true}, "a-bc", true},
+		{"word shorter match only inside word", []string{`abc|ab`}, MatchOptions{Word: true}, "abcd", false},
+		{"word begin anchor inside line", []string{`^b`}, MatchOptions{Word: true}, "a b", false},
+		{"word end anchor after shrinking", []string{`abc|ab$`}, MatchOptions{Word: true}, "abcd", false},
+		{"word ignore case fixed", []string{"a", "a-b"}, MatchOptions{Fixed: true, Word: true, IgnoreCase: true}, "A-Bc", true},
+		{"whole line regex", []string{`a.c`}, MatchOptions{Line: true}, "abc", true},
+		{"whole line regex partial", []string{`a.c`}, MatchOptions{Line: true}, "abcd", false},
+		{"whole line fixed", []string{"abc"}, MatchOptions{Fixed: true, Line: true}, "abc", true},
+		{"whole line several fixed", []string{"a", "abc"}, MatchOptions{Fixed: true, Line: true}, "abc", true},
+		{"whole line alternatives", []string{"a", "ab"}, MatchOptions{Line: true}, "ab", true},
+		{"several patterns", []string{"foo", "bar"}, MatchOptions{}, "xbarx", true},
+		{"several fixed patterns", []string{"foo", "bar"}, MatchOptions{Fixed: true}, "baz", false},
+		{"several fixed patterns match", []string{"foo", "bar", "az"}, MatchOptions{Fixed: true}, "baz", true},
+		{"empty pattern matches all", []string{""}, MatchOptions{}, "anything", true},
+		{"empty fixed pattern among others", []string{"x", ""}, MatchOptions{Fixed: true}, "anything", true},
+		{"no patterns match nothing", nil, MatchOptions{}, "anything", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := New(test.patterns, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Match(test.line); got != test.want {
+				t.Errorf("Match(%q) = %v, want %v", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewImplementation(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     MatchOptions
+		want     Matcher
+	}{
+		{"regex", []string{"a+"}, MatchOptions{}, &regexMatcher{}},
+		{"single fixed", []string{"a"}, MatchOptions{Fixed: true}, &fixedMatcher{}},
+		{"several fixed", []string{"a", "b"}, MatchOptions{Fixed: true}, &ahoCorasick{}},
+		{"no patterns", nil, MatchOptions{}, &fixedMatcher{}},
+	}
+	for _, test := range tests {
+		m, err := New(test.patterns, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reflect.TypeOf(m) != reflect.TypeOf(test.want) {
+			t.Errorf("%s: got %T, want %T", test.name, m, test.want)
+		}
+	}
+}
+
+func TestNewRegexErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		opts    MatchOptions
+	}{
+		{`a(b`, MatchOptions{}},
+		{`(?:ab)`, MatchOptions{Extended: true}},
+		{`a\`, MatchOptions{IgnoreCase: true}},
+	}
+	for _, test := range tests {
+		if _, err := NewRegex([]string{test.pattern}, test.opts); err == nil {
+			t.Errorf("expected error for %q", test.pattern)
+		}
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     MatchOptions
+		line     string
+		want     [][]int
+	}{
+		{"regex", []string{`o+`}, MatchOptions{}, "foo boo", [][]int{{1, 3}, {5, 7}}},
+		{"empty matches skipped", []string{`x*`}, MatchOptions{}, "axb", [][]int{{1, 2}}},
+		{"fixed", []string{"ab"}, MatchOptions{Fixed: true}, "abcab", [][]int{{0, 2}, {3, 5}}},
+		{"fixed longest at same start", []string{"a", "abc"}, MatchOptions{Fixed: true}, "xabc", [][]int{{1, 4}}},
+		{"fixed earliest wins", []string{"cd", "b"}, MatchOptions{Fixed: true}, "abcd", [][]int{{1, 2}, {2, 4}}},
+		{"fixed overlapping", []string{"abcd", "bc", "d"}, MatchOptions{Fixed: true}, "abcabcd", [][]int{{1, 3}, {3, 7}}},
+		{"fixed word", []string{"cat"}, MatchOptions{Fixed: true, Word: true}, "cats cat", [][]int{{5, 8}}},
+		{"fixed word shorter at same start", []string{"a", "a-b"}, MatchOptions{Fixed: true, Word: true}, "a-bc", [][]int{{0, 1}}},
+		{"regex word", []string{"cat"}, MatchOptions{Word: true}, "cat concat", [][]int{{0, 3}}},
+		{"regex word shorter alternative", []string{`ab|abc`}, MatchOptions{Word: true}, "abc ab abcd", [][]int{{0, 3}, {4, 6}}},
+		{"regex word shorter match at same start", []string{`a-b|a`}, MatchOptions{Word: true}, "a-bc a-b", [][]int{{0, 1}, {5, 8}}},
+		{"regex word anchors", []string{`^a|b$`}, MatchOptions{Word: true}, "a a b b", [][]int{{0, 1}, {6, 7}}},
+		{"extended word", []string{`ab|abc`}, MatchOptions{Extended: true, Word: true}, "abc", [][]int{{0, 3}}},
+		{"fixed whole line", []string{"abc"}, MatchOptions{Fixed: true, Line: true}, "abc", [][]int{{0, 3}}},
+		{"ignore case", []string{"ab"}, MatchOptions{Fixed: true, IgnoreCase: true}, "xAB", [][]int{{1, 3}}},
+		{"ignore case several", []string{"ab", "C"}, MatchOptions{Fixed: true, IgnoreCase: true}, "xABc", [][]int{{1, 3}, {3, 4}}},
+		{"no match", []string{"z"}, MatchOptions{}, "abc", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := New(test.patterns, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.FindAll(test.line); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("FindAll(%q) = %v, want %v", test.line, got, test.want)
+			}
+		})
+	}
+}
+
+// randomString возвращает строку длины n из символов alphabet
+func randomString(rng *rand.Rand, alphabet string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(alphabet[rng.Intn(len(alphabet))])
+	}
+	return b.String()
+}
+
+// TestAhoCorasickMatchesFixed сравнивает автомат с поиском подстрок на случайных данных
+func TestAhoCorasickMatchesFixed(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const alphabet = "abAB _"
+	for i := 0; i < 2000; i++ {
+		patterns := make([]string, 1+rng.Intn(5))
+		for j := range patterns {
+			patterns[j] = randomString(rng, alphabet, rng.Intn(4))
+		}
+		opts := MatchOptions{Word: rng.Intn(3) == 0, Line: rng.Intn(5) == 0, IgnoreCase: rng.Intn(3) == 0}
+		line := randomString(rng, alphabet, rng.Intn(12))
+
+		ac := NewAhoCorasick(patterns, opts)
+		// Без учета регистра NewFixed ищет регулярным выражением с другим выбором
+		// среди альтернатив, поэтому сравниваем с поиском в нижнем регистре
+		fixed, folded := NewFixed(patterns, opts), line
+		if opts.IgnoreCase {
+			lower := make([]string, len(patterns))
+			for j, pattern := range patterns {
+				lower[j] = strings.ToLower(pattern)
+			}
+			fixed = NewFixed(lower, MatchOptions{Word: opts.Word, Line: opts.Line})
+			folded = strings.ToLower(line)
+		}
+		if got, want := ac.Match(line), fixed.Match(folded); got != want {
+			t.Fatalf("patterns %q, opts %+v: Match(%q) = %v, want %v", patterns, opts, line, got, want)
+		}
+		if got, want := ac.FindAll(line), fixed.FindAll(folded); !reflect.DeepEqual(got, want) {
+			t.Fatalf("patterns %q, opts %+v: FindAll(%q) = %v, want %v", patterns, opts, line, got, want)
+		}
+	}
+}
+
+func BenchmarkFixedManyPatterns(b *testing.B) {
+	benchmarkManyPatterns(b, NewFixed)
+}
+
+func BenchmarkAhoCorasickManyPatterns(b *testing.B) {
+	benchmarkManyPatterns(b, NewAhoCorasick)
+}
+
+// benchmarkManyPatterns ищет 200 фиксированных строк в строках журнала
+func benchmarkManyPatterns(b *testing.B, newMatcher func([]string, MatchOptions) Matcher) {
+	rng := rand.New(rand.NewSource(1))
+	patterns := make([]string, 200)
+	for i := range patterns {
+		patterns[i] = randomString(rng, "abcdefghijklmnopqrstuvwxyz", 8)
+	}
+	line := strings.Repeat("2024-01-01 12:00:00 INFO request handled in 12ms ", 4)
+	m := newMatcher(patterns, MatchOptions{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(line)
+	}
+}
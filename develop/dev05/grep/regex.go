@@ -0,0 +1,234 @@
+package grep
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+)
+
+// regexMatcher ищет объединенное регулярное выражение всех шаблонов
+type regexMatcher struct {
+	opts MatchOptions
+	re   *regexp.Regexp
+	word *wordRegexps // Варианты выражения для -w; nil без Word
+}
+
+// wordRegexps — варианты выражения для поиска целых слов (-w) с позиции внутри
+// строки. Поиск с позиции в Go возможен только по подстроке, поэтому привязки
+// к началу и концу строки, которых в подстроке нет, отключаются. Привязанные
+// варианты ищут самое длинное совпадение: из него, как в GNU grep,
+// получаются более короткие совпадения с того же начала.
+type wordRegexps struct {
+	inner     *regexp.Regexp // Поиск в подстроке, начинающейся внутри строки
+	longest   *regexp.Regexp // Самое длинное совпадение в начале строки
+	longestIn *regexp.Regexp // То же с позиции внутри строки
+	shorter   *regexp.Regexp // Совпадение в начале строки, обрезанной справа
+	shorterIn *regexp.Regexp // То же с позиции внутри строки
+}
+
+// NewRegex компилирует шаблоны в одно регулярное выражение. По умолчанию
+// шаблоны — регулярные выражения RE2; с Extended они проверяются по синтаксису
+// POSIX ERE и ищется самое длинное совпадение; с Fixed экранируются.
+func NewRegex(patterns []string, opts MatchOptions) (Matcher, error) {
+	alternatives := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		switch {
+		case opts.Fixed:
+			pattern = regexp.QuoteMeta(pattern)
+		case opts.Extended:
+			// Синтаксис ERE проверяем отдельно: в объединенном выражении
+			// нужны флаги и незахватывающие группы, которых нет в POSIX
+			if _, err := regexp.CompilePOSIX(pattern); err != nil {
+				return nil, err
+			}
+		}
+		alternatives[i] = "(?:" + pattern + ")"
+	}
+
+	expr := strings.Join(alternatives, "|")
+	// Без шаблонов выражение не должно совпадать ни с чем
+	if len(patterns) == 0 {
+		expr = `[^\x00-\x{10FFFF}]`
+	}
+	if opts.Line {
+		expr = "^(?:" + expr + ")$"
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Extended {
+		re.Longest()
+	}
+	m := &regexMatcher{opts: opts, re: re}
+	if opts.Word {
+		if m.word, err = newWordRegexps(expr); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// newWordRegexps компилирует варианты выражения expr для поиска целых слов
+func newWordRegexps(expr string) (*wordRegexps, error) {
+	var w wordRegexps
+	var err error
+	variants := []struct {
+		re                       **regexp.Regexp
+		noBegin, noEnd, anchored bool
+	}{
+		{&w.inner, true, false, false},
+		{&w.longest, false, false, true},
+		{&w.longestIn, true, false, true},
+		{&w.shorter, false, true, true},
+		{&w.shorterIn, true, true, true},
+	}
+	for _, v := range variants {
+		if *v.re, err = compileVariant(expr, v.noBegin, v.noEnd, v.anchored); err != nil {
+			return nil, err
+		}
+		(*v.re).Longest()
+	}
+	return &w, nil
+}
+
+// compileVariant компилирует expr, в котором привязки к началу (noBegin)
+// или концу (noEnd) строки никогда не выполняются; при anchored совпадение
+// ищется только в начале текста
+func compileVariant(expr string, noBegin, noEnd, anchored bool) (*regexp.Regexp, error) {
+	tree, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	disableAnchors(tree, noBegin, noEnd)
+	expr = tree.String()
+	if anchored {
+		expr = `\A(?:` + expr + `)`
+	}
+	return regexp.Compile(expr)
+}
+
+// disableAnchors заменяет привязки к началу или концу строки
+// выражением, которое ни с чем не совпадает
+func disableAnchors(re *syntax.Regexp, begin, end bool) {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpBeginText:
+		if begin {
+			*re = syntax.Regexp{Op: syntax.OpNoMatch}
+		}
+	case syntax.OpEndLine, syntax.OpEndText:
+		if end {
+			*re = syntax.Regexp{Op: syntax.OpNoMatch}
+		}
+	}
+	for _, sub := range re.Sub {
+		disableAnchors(sub, begin, end)
+	}
+}
+
+// Match реализует Matcher
+func (m *regexMatcher) Match(line string) bool {
+	if !m.opts.Word {
+		return m.re.MatchString(line)
+	}
+	start, _ := m.findWord(line, 0)
+	return start >= 0
+}
+
+// FindAll реализует Matcher
+func (m *regexMatcher) FindAll(line string) [][]int {
+	if m.opts.Word {
+		var result [][]int
+		for pos := 0; pos <= len(line); {
+			start, end := m.findWord(line, pos)
+			if start < 0 {
+				break
+			}
+			if start == end {
+				pos = nextRune(line, start)
+				continue
+			}
+			result = append(result, []int{start, end})
+			pos = end
+		}
+		return result
+	}
+
+	var result [][]int
+	for _, loc := range m.re.FindAllStringIndex(line, -1) {
+		if loc[0] != loc[1] {
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+// findWord ищет с позиции pos совпадение, которое является целым словом (-w).
+// Как в GNU grep, если самое длинное совпадение с самого левого начала не
+// является словом, проверяются более короткие совпадения с того же начала,
+// а затем поиск продолжается со следующего символа. Возвращает -1, -1,
+// если совпадения нет.
+//
+// Поиск с pos идет по подстроке, где граница \b в ее начале вычисляется без
+// предыдущего символа. Ошибка возможна, только если этот символ входит
+// в слово, но тогда совпадение с pos все равно не является словом.
+func (m *regexMatcher) findWord(line string, pos int) (int, int) {
+	for pos <= len(line) {
+		start := m.searchFrom(line, pos)
+		if start < 0 {
+			break
+		}
+		end := m.matchAt(line, start, len(line))
+		for end >= 0 {
+			if isWordMatch(line, start, end) {
+				return start, end
+			}
+			if end == start {
+				break
+			}
+			// Более короткое совпадение заканчивается хотя бы на символ раньше
+			_, size := utf8.DecodeLastRuneInString(line[start:end])
+			end = m.matchAt(line, start, end-size)
+		}
+		pos = nextRune(line, start)
+	}
+	return -1, -1
+}
+
+// searchFrom возвращает начало самого левого совпадения с позиции pos или -1
+func (m *regexMatcher) searchFrom(line string, pos int) int {
+	re := m.re
+	if pos > 0 {
+		re = m.word.inner
+	}
+	loc := re.FindStringIndex(line[pos:])
+	if loc == nil {
+		return -1
+	}
+	return pos + loc[0]
+}
+
+// matchAt возвращает конец самого длинного совпадения, которое начинается
+// в start и заканчивается не позже limit, или -1
+func (m *regexMatcher) matchAt(line string, start, limit int) int {
+	var re *regexp.Regexp
+	switch {
+	case limit == len(line) && start == 0:
+		re = m.word.longest
+	case limit == len(line):
+		re = m.word.longestIn
+	case start == 0:
+		re = m.word.shorter
+	default:
+		re = m.word.shorterIn
+	}
+	loc := re.FindStringIndex(line[start:limit])
+	if loc == nil {
+		return -1
+	}
+	return start + loc[1]
+}
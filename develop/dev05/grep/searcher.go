@@ -0,0 +1,178 @@
+package grep
+
+import (
+	"bufio"
+	"io"
+
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// maxLineSize — максимальная длина строки, которую может прочитать Searcher
+const maxLineSize = 1 << 30
+
+// Line — строка, переданная в Sink
+type Line struct {
+	Number   int     // Номер строки (с 1)
+	Offset   int64   // Смещение начала строки от начала входа в байтах
+	Text     string  // Строка без перевода строки
+	Matches  [][]int // Границы совпадений в Text; заполняются с SearchOptions.Submatches
+	Selected bool    // Строка выбрана; false — строка контекста
+}
+
+// Stats — итоги поиска по одному входу
+type Stats struct {
+	Selected      int   // Число выбранных строк
+	BytesSearched int64 // Сколько байт входа прочитано
+}
+
+// Sink получает события поиска. Ошибка любого метода прекращает поиск.
+type Sink interface {
+	// Line получает выбранную строку или строку контекста
+	Line(line Line) error
+	// Separator отделяет несмежные группы строк, когда контекст включен
+	Separator() error
+	// Finish вызывается после поиска
+	Finish(stats Stats) error
+}
+
+// SearchOptions — параметры поиска
+type SearchOptions struct {
+	Before     int  // Число строк контекста до выбранной строки (-B)
+	After      int  // Число строк контекста после выбранной строки (-A)
+	Invert     bool // Выбирать несовпадающие строки (-v)
+	MaxCount   int  // Остановиться после стольких выбранных строк (-m); 0 — без ограничения
+	Count      bool // Только подсчитать выбранные строки, не передавая их в Sink (-c)
+	Submatches bool // Заполнять Line.Matches
+}
+
+// Searcher ищет строки, совпадающие с Matcher, в потоке данных
+type Searcher struct {
+	matcher Matcher
+	opts    SearchOptions
+}
+
+// NewSearcher создает поиск по сопоставителю m
+func NewSearcher(m Matcher, opts SearchOptions) *Searcher {
+	return &Searcher{matcher: m, opts: opts}
+}
+
+// Search читает r построчно и передает в sink выбранные строки вместе
+// с контекстом. Данные читаются потоково, в памяти хранится не больше
+// Before строк.
+//
+// Строки контекста после совпадения тоже проверяются на совпадение, поэтому
+// совпадение внутри контекста начинает новое окно. Перекрывающиеся и соседние
+// окна передаются одной группой, между несмежными группами вызывается
+// Separator. После MaxCount выбранных строк передается только оставшийся
+// контекст после них.
+func (s *Searcher) Search(r io.Reader, sink Sink) (Stats, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	scanner.Split(textcli.ScanRecords('\n'))
+
+	opts := s.opts
+	useContext := opts.Before > 0 || opts.After > 0
+	beforeLines := newRingBuffer(opts.Before)
+	lastSent := 0  // Номер последней переданной строки, 0 — строк еще не было
+	afterLeft := 0 // Сколько строк контекста после совпадения осталось передать
+
+	send := func(line Line, selected bool) error {
+		if useContext && lastSent > 0 && line.Number > lastSent+1 {
+			if err := sink.Separator(); err != nil {
+				return err
+			}
+		}
+		line.Selected = selected
+		if opts.Submatches {
+			line.Matches = s.matcher.FindAll(line.Text)
+		}
+		lastSent = line.Number
+		return sink.Line(line)
+	}
+
+	var stats Stats
+	var err error
+	for num := 1; err == nil && scanner.Scan(); num++ {
+		line := Line{Number: num, Offset: stats.BytesSearched, Text: scanner.Text()}
+		stats.BytesSearched += int64(len(line.Text)) + 1
+
+		if opts.MaxCount > 0 && stats.Selected >= opts.MaxCount {
+			// После последней выбранной строки дочитываем только ее контекст
+			if afterLeft == 0 || opts.Count {
+				break
+			}
+			err = send(line, false)
+			afterLeft--
+			continue
+		}
+
+		if s.matcher.Match(line.Text) != opts.Invert {
+			stats.Selected++
+			if opts.Count {
+				continue
+			}
+			beforeLines.drain(func(context Line) {
+				if err == nil {
+					err = send(context, false)
+				}
+			})
+			if err == nil {
+				err = send(line, true)
+			}
+			afterLeft = opts.After
+			continue
+		}
+		if opts.Count {
+			continue
+		}
+
+		if afterLeft > 0 {
+			err = send(line, false)
+			afterLeft--
+		} else {
+			beforeLines.push(line)
+		}
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	return stats, sink.Finish(stats)
+}
+
+// ringBuffer хранит последние строки перед совпадением (-B).
+// Старые строки вытесняются новыми, поэтому память не растет с размером входа.
+type ringBuffer struct {
+	lines []Line
+	start int // Индекс самой старой строки
+	size  int // Число строк в буфере
+}
+
+// newRingBuffer создает буфер на capacity строк
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{lines: make([]Line, capacity)}
+}
+
+// push добавляет строку, вытесняя самую старую при заполненном буфере
+func (b *ringBuffer) push(line Line) {
+	if len(b.lines) == 0 {
+		return
+	}
+	if b.size < len(b.lines) {
+		b.lines[(b.start+b.size)%len(b.lines)] = line
+		b.size++
+		return
+	}
+	b.lines[b.start] = line
+	b.start = (b.start + 1) % len(b.lines)
+}
+
+// drain вызывает fn для строк буфера от старой к новой и очищает буфер
+func (b *ringBuffer) drain(fn func(Line)) {
+	for i := 0; i < b.size; i++ {
+		fn(b.lines[(b.start+i)%len(b.lines)])
+	}
+	b.start, b.size = 0, 0
+}
@@ -0,0 +1,130 @@
+package grep
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordSink записывает события поиска в виде строк
+type recordSink struct {
+	events []string
+	stats  Stats
+	err    error // Ошибка, которую возвращает Line
+}
+
+// Line реализует Sink
+func (s *recordSink) Line(line Line) error {
+	kind := "context"
+	if line.Selected {
+		kind = "match"
+	}
+	s.events = append(s.events, fmt.Sprintf("%s %d@%d %q %v", kind, line.Number, line.Offset, line.Text, line.Matches))
+	return s.err
+}
+
+// Separator реализует Sink
+func (s *recordSink) Separator() error {
+	s.events = append(s.events, "--")
+	return nil
+}
+
+// Finish реализует Sink
+func (s *recordSink) Finish(stats Stats) error {
+	s.stats = stats
+	s.events = append(s.events, "finish")
+	return nil
+}
+
+func TestRingBuffer(t *testing.T) {
+	buf := newRingBuffer(3)
+	for i := 1; i <= 5; i++ {
+		buf.push(Line{Number: i})
+	}
+	var got []int
+	buf.drain(func(line Line) { got = append(got, line.Number) })
+	if want := []int{3, 4, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = nil
+	buf.drain(func(line Line) { got = append(got, line.Number) })
+	if len(got) != 0 {
+		t.Errorf("drained buffer is not empty: %v", got)
+	}
+}
+
+func TestSearcher(t *testing.T) {
+	input := "a\nmatch1\nb\nc\nmatch2 match\r\nd\n"
+	tests := []struct {
+		name      string
+		opts      SearchOptions
+		want      []string
+		wantCount int
+	}{
+		{"no context", SearchOptions{}, []string{
+			`match 2@2 "match1" []`, `match 5@13 "match2 match\r" []`, "finish",
+		}, 2},
+		{"submatches", SearchOptions{Submatches: true}, []string{
+			`match 2@2 "match1" [[0 5]]`, `match 5@13 "match2 match\r" [[0 5] [7 12]]`, "finish",
+		}, 2},
+		{"context and separator", SearchOptions{Before: 1}, []string{
+			`context 1@0 "a" []`, `match 2@2 "match1" []`, "--",
+			`context 4@11 "c" []`, `match 5@13 "match2 match\r" []`, "finish",
+		}, 2},
+		{"after context merges windows", SearchOptions{After: 2}, []string{
+			`match 2@2 "match1" []`, `context 3@9 "b" []`, `context 4@11 "c" []`,
+			`match 5@13 "match2 match\r" []`, `context 6@27 "d" []`, "finish",
+		}, 2},
+		{"invert", SearchOptions{Invert: true, MaxCount: 2}, []string{
+			`match 1@0 "a" []`, `match 3@9 "b" []`, "finish",
+		}, 2},
+		{"max count with trailing context", SearchOptions{MaxCount: 1, After: 1}, []string{
+			`match 2@2 "match1" []`, `context 3@9 "b" []`, "finish",
+		}, 1},
+		{"count", SearchOptions{Count: true, Before: 1}, []string{"finish"}, 2},
+	}
+
+	m := NewFixed([]string{"match"}, MatchOptions{})
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := &recordSink{}
+			stats, err := NewSearcher(m, test.opts).Search(strings.NewReader(input), sink)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(sink.events, test.want) {
+				t.Errorf("got events\n%q\nwant\n%q", sink.events, test.want)
+			}
+			if stats.Selected != test.wantCount || sink.stats != stats {
+				t.Errorf("got stats %+v, finish %+v, want %d selected", stats, sink.stats, test.wantCount)
+			}
+		})
+	}
+}
+
+func TestSearcherBytesSearched(t *testing.T) {
+	stats, err := NewSearcher(NewFixed([]string{"x"}, MatchOptions{}), SearchOptions{}).
+		Search(strings.NewReader("ab\ncd\n"), &recordSink{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.BytesSearched != 6 || stats.Selected != 0 {
+		t.Errorf("got %+v", stats)
+	}
+}
+
+func TestSearcherSinkError(t *testing.T) {
+	errStop := errors.New("stop")
+	sink := &recordSink{err: errStop}
+	_, err := NewSearcher(NewFixed([]string{"a"}, MatchOptions{}), SearchOptions{}).
+		Search(strings.NewReader("a1\na2\na3\n"), sink)
+	if !errors.Is(err, errStop) {
+		t.Fatalf("got error %v, want %v", err, errStop)
+	}
+	if len(sink.events) != 1 {
+		t.Errorf("search continued after sink error: %q", sink.events)
+	}
+}
@@ -0,0 +1,229 @@
+package grepcmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Сигнатуры сжатых файлов и архивов. Формат определяется по содержимому,
+// а не по расширению, поэтому ротированные логи без суффикса тоже распознаются.
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	xzMagic    = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic   = []byte("PK\x03\x04")
+	tarMagic   = []byte("ustar")
+
+	// После "BZh" и цифры размера блока идет сигнатура первого блока
+	// или, для пустого потока, сигнатура его конца
+	bzip2BlockMagic = []byte{0x31, 0x41, 0x59, 0x26, 0x53, 0x59}
+	bzip2EndMagic   = []byte{0x17, 0x72, 0x45, 0x38, 0x50, 0x90}
+)
+
+const (
+	tarMagicOffset   = 257 // Смещение сигнатуры "ustar" в заголовке tar
+	bzip2BlockOffset = 4   // Смещение сигнатуры блока bzip2
+)
+
+// headerSize — сколько байт от начала данных проверяется распаковщиком
+// до того, как данные считаются сжатыми
+const headerSize = 512
+
+// hasMagic сообщает, что данные начинаются с сигнатуры magic
+func hasMagic(r *bufio.Reader, offset int, magic []byte) bool {
+	head, _ := r.Peek(offset + len(magic))
+	return len(head) == offset+len(magic) && bytes.Equal(head[offset:], magic)
+}
+
+// isBzip2 сообщает, что данные начинаются с заголовка bzip2: трех байт
+// "BZh" мало, чтобы отличить сжатый файл от текста
+func isBzip2(r *bufio.Reader) bool {
+	if !hasMagic(r, 0, bzip2Magic) {
+		return false
+	}
+	head, _ := r.Peek(len(bzip2Magic) + 1)
+	if level := head[len(head)-1]; level < '1' || level > '9' {
+		return false
+	}
+	return hasMagic(r, bzip2BlockOffset, bzip2BlockMagic) || hasMagic(r, bzip2BlockOffset, bzip2EndMagic)
+}
+
+// validHeader сообщает, что распаковщик open принимает начало данных r.
+// Сигнатура могла совпасть с началом обычного текста; данные, обрезанные
+// на headerSize байтах, ошибкой не считаются.
+func validHeader(r *bufio.Reader, open func(io.Reader) error) bool {
+	head, _ := r.Peek(headerSize)
+	err := open(bytes.NewReader(head))
+	return err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// openGzip проверяет заголовок gzip
+func openGzip(r io.Reader) error {
+	_, err := gzip.NewReader(r)
+	return err
+}
+
+// openXz проверяет заголовок xz
+func openXz(r io.Reader) error {
+	_, err := xz.NewReader(r)
+	return err
+}
+
+// decompress возвращает распакованный поток для сжатых данных r или сам r,
+// если формат сжатия не распознан или заголовок не принят распаковщиком.
+// close освобождает ресурсы распаковщика.
+func decompress(r *bufio.Reader) (data io.Reader, close func(), err error) {
+	switch {
+	case hasMagic(r, 0, gzipMagic) && validHeader(r, openGzip):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close() }, nil
+	case isBzip2(r):
+		return bzip2.NewReader(r), func() {}, nil
+	case hasMagic(r, 0, xzMagic) && validHeader(r, openXz):
+		xr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return xr, func() {}, nil
+	case hasMagic(r, 0, zstdMagic):
+		// Файлы и так обрабатываются параллельно (-j), поэтому распаковщику
+		// достаточно одной горутины
+		zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	}
+	return r, func() {}, nil
+}
+
+// searchArchive ищет во входе name с распаковкой (-z). Сжатый файл
+// распаковывается, в архивах tar и zip поиск идет по каждому файлу,
+// а найденные строки выводятся с именем "архив:путь/в/архиве".
+// input — исходный файл, reader — буфер чтения поверх него.
+func (g *grepper) searchArchive(name string, input io.Reader, reader *bufio.Reader, stdout, stderr io.Writer) {
+	data, closeData, err := decompress(reader)
+	if err != nil {
+		g.reportError(name, err, stderr)
+		return
+	}
+	defer closeData()
+
+	compressed := data != io.Reader(reader)
+	if compressed {
+		reader = readerPool.Get().(*bufio.Reader)
+		reader.Reset(data)
+		defer readerPool.Put(reader)
+	}
+
+	switch {
+	case hasMagic(reader, 0, zipMagic):
+		g.searchZipArchive(name, input, reader, compressed, stdout, stderr)
+	case hasMagic(reader, tarMagicOffset, tarMagic):
+		g.searchTarArchive(name, reader, stdout, stderr)
+	default:
+		g.searchInput(name, g.showNames, reader, stdout, stderr)
+	}
+}
+
+// searchZipArchive ищет в файлах архива zip. Оглавление zip находится в конце архива,
+// поэтому обычный файл читается с произвольным доступом, а стандартный ввод
+// и сжатый архив сначала считываются в память.
+func (g *grepper) searchZipArchive(name string, input io.Reader, reader *bufio.Reader, compressed bool, stdout, stderr io.Writer) {
+	var archive io.ReaderAt
+	var size int64
+	if file, ok := input.(*os.File); ok && !compressed && file != os.Stdin {
+		info, err := file.Stat()
+		if err != nil {
+			g.reportError(name, err, stderr)
+			return
+		}
+		archive, size = file, info.Size()
+	} else {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			g.reportError(name, err, stderr)
+			return
+		}
+		archive, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	zr, err := zip.NewReader(archive, size)
+	if err != nil {
+		g.reportError(name, err, stderr)
+		return
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if g.quiet && g.matched.Load() {
+			return
+		}
+		entry, err := f.Open()
+		if err != nil {
+			g.reportError(name+":"+f.Name, err, stderr)
+			continue
+		}
+		g.searchEntry(name+":"+f.Name, entry, stdout, stderr)
+		entry.Close()
+	}
+}
+
+// searchTarArchive ищет в обычных файлах архива tar
+func (g *grepper) searchTarArchive(name string, reader *bufio.Reader, stdout, stderr io.Writer) {
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			g.reportError(name, err, stderr)
+			return
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if g.quiet && g.matched.Load() {
+			return
+		}
+		g.searchEntry(name+":"+header.Name, tr, stdout, stderr)
+	}
+}
+
+// searchEntry ищет в файле из архива; сжатый файл внутри архива тоже распаковывается
+func (g *grepper) searchEntry(name string, r io.Reader, stdout, stderr io.Writer) {
+	reader := readerPool.Get().(*bufio.Reader)
+	reader.Reset(r)
+	defer readerPool.Put(reader)
+
+	data, closeData, err := decompress(reader)
+	if err != nil {
+		g.reportError(name, err, stderr)
+		return
+	}
+	defer closeData()
+	if data != io.Reader(reader) {
+		reader = readerPool.Get().(*bufio.Reader)
+		reader.Reset(data)
+		defer readerPool.Put(reader)
+	}
+
+	// Файлов в архиве может быть много, поэтому имя выводится всегда, кроме -h
+	g.searchInput(name, !g.hideNames, reader, stdout, stderr)
+}
@@ -0,0 +1,401 @@
+package grepcmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/encoding"
+
+	"wb-tech-l2/develop/dev05/grep"
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// Коды завершения, как у POSIX grep
+const (
+	exitMatch   = textcli.ExitOK    // Выбрана хотя бы одна строка
+	exitNoMatch = textcli.ExitFalse // Ни одна строка не выбрана
+	exitError   = textcli.ExitError // Ошибка в аргументах или при чтении файлов
+)
+
+// stdinName — имя стандартного ввода в выводе
+const stdinName = "(standard input)"
+
+// usage — краткая справка при неверных аргументах
+const usage = "Использование: grep [ПАРАМЕТР]... ШАБЛОНЫ [ФАЙЛ]..."
+
+// Run выполняет поиск с аргументами командной строки и возвращает код завершения
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("grep", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	// Определение флагов командной строки
+	after := fs.Int("A", 0, "Выводить N строк после совпадения")
+	before := fs.Int("B", 0, "Выводить N строк до совпадения")
+	context := fs.Int("C", 0, "Выводить N строк до и после совпадения")
+	count := fs.Bool("c", false, "Выводить только число совпавших строк")
+	ignoreCase := fs.Bool("i", false, "Не различать регистр")
+	invert := fs.Bool("v", false, "Выбирать строки без совпадений")
+	fixed := fs.Bool("F", false, "Искать шаблоны как фиксированные строки, а не регулярные выражения")
+	extended := fs.Bool("E", false, "Разбирать шаблоны как расширенные регулярные выражения POSIX")
+	word := fs.Bool("w", false, "Искать только целые слова")
+	wholeLine := fs.Bool("x", false, "Искать только строки целиком")
+	lineNum := fs.Bool("n", false, "Выводить номера строк")
+	var patterns patternList
+	fs.Var(&patterns, "e", "Шаблон поиска, можно указать несколько раз")
+	patternFile := fs.String("f", "", "Читать шаблоны из файла, по одному в строке")
+	recursive := fs.Bool("r", false, "Искать в каталогах рекурсивно, пропуская символические ссылки внутри них")
+	dereference := fs.Bool("R", false, "Искать в каталогах рекурсивно, переходя по всем символическим ссылкам")
+	withName := fs.Bool("H", false, "Выводить имя файла для каждого совпадения")
+	noName := fs.Bool("h", false, "Не выводить имена файлов")
+	listMatching := fs.Bool("l", false, "Выводить только имена файлов с совпадениями")
+	listNonMatching := fs.Bool("L", false, "Выводить только имена файлов без совпадений")
+	var include, exclude, excludeDir patternList
+	fs.Var(&include, "include", "Искать только в файлах, имя которых подходит под маску; можно указать несколько раз")
+	fs.Var(&exclude, "exclude", "Пропускать файлы, имя которых подходит под маску; можно указать несколько раз")
+	fs.Var(&excludeDir, "exclude-dir", "Пропускать при рекурсивном поиске каталоги, имя которых подходит под маску; можно указать несколько раз")
+	noIgnore := fs.Bool("no-ignore", false, "Не учитывать файлы .gitignore при рекурсивном поиске")
+	binaryFiles := fs.String("binary-files", "binary", "Обработка двоичных файлов: binary, without-match или text")
+	skipBinary := fs.Bool("I", false, "Пропускать двоичные файлы (то же, что --binary-files=without-match)")
+	text := fs.Bool("a", false, "Искать в двоичных файлах как в тексте (то же, что --binary-files=text)")
+	jobs := fs.Int("j", runtime.NumCPU(), "Число файлов, в которых поиск идет параллельно")
+	color := fs.String("color", "never", "Подсветка совпадений: auto, always или never (цвета из GREP_COLORS)")
+	fs.StringVar(color, "colour", "never", "То же, что --color")
+	onlyMatching := fs.Bool("o", false, "Выводить только совпавшие части строк")
+	byteOffset := fs.Bool("b", false, "Выводить смещение в байтах каждой строки (с -o — каждого совпадения)")
+	maxCount := fs.Int("m", -1, "Прекращать чтение файла после N выбранных строк")
+	lineBuffered := fs.Bool("line-buffered", false, "Сбрасывать вывод после каждой строки (всегда, если вывод в терминал)")
+	jsonOutput := fs.Bool("json", false, "Выводить результаты событиями JSON Lines в формате ripgrep")
+	quiet := fs.Bool("q", false, "Ничего не выводить и завершаться с кодом 0 при первом совпадении")
+	suppressErrors := fs.Bool("s", false, "Не сообщать о несуществующих и недоступных для чтения файлах")
+	var searchZip bool
+	fs.BoolVar(&searchZip, "z", false, "Искать в файлах, сжатых gzip, bzip2, xz и zstd, и в архивах tar и zip")
+	fs.BoolVar(&searchZip, "search-zip", false, "То же, что -z")
+	encodingName := fs.String("encoding", "", "Кодировка входных данных (windows-1251, koi8-r, utf-16le...); вывод всегда в UTF-8")
+
+	// Парсим флаги
+	if err := fs.Parse(textcli.ExpandShortFlags(fs, expandColorFlag(args))); err != nil {
+		return exitError
+	}
+
+	// fail выводит ошибку в stderr и возвращает код ошибки
+	fail := func(a ...interface{}) int {
+		return textcli.Fail(stderr, "grep", a...)
+	}
+
+	// Позиционные аргументы
+	args = fs.Args()
+
+	// Шаблоны из файла -f добавляются к шаблонам -e
+	if *patternFile != "" {
+		filePatterns, err := readPatterns(*patternFile)
+		if err != nil {
+			return fail(textcli.DescribeError(*patternFile, err))
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	// Без -e и -f шаблон поиска — первый позиционный аргумент
+	if len(patterns) == 0 && *patternFile == "" {
+		if len(args) == 0 {
+			fmt.Fprintln(stderr, usage)
+			return exitError
+		}
+		patterns.Set(args[0])
+		args = args[1:]
+	}
+
+	// Без файлов ищем в стандартном вводе, а при рекурсивном поиске —
+	// в текущем каталоге
+	isRecursive := *recursive || *dereference
+	if len(args) == 0 {
+		args = []string{textcli.StdinName}
+		if isRecursive {
+			args = []string{"."}
+		}
+	}
+
+	m, err := grep.New(patterns, grep.MatchOptions{
+		Fixed: *fixed, Extended: *extended, IgnoreCase: *ignoreCase, Word: *word, Line: *wholeLine,
+	})
+	if err != nil {
+		return fail("неверный шаблон:", err)
+	}
+
+	switch {
+	case *skipBinary:
+		*binaryFiles = "without-match"
+	case *text:
+		*binaryFiles = "text"
+	}
+	if *binaryFiles != "binary" && *binaryFiles != "without-match" && *binaryFiles != "text" {
+		return fail("неверное значение --binary-files:", *binaryFiles)
+	}
+
+	enc, err := textcli.LookupEncoding(*encodingName)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *jobs < 1 {
+		return fail("число файлов -j должно быть больше нуля:", *jobs)
+	}
+
+	if *color != "auto" && *color != "always" && *color != "never" {
+		return fail("неверное значение --color:", *color)
+	}
+	if *jsonOutput && (*count || *listMatching || *listNonMatching) {
+		return fail("--json нельзя сочетать с -c, -l и -L")
+	}
+	var report *jsonReport
+	if *jsonOutput && !*quiet {
+		report = newJSONReport()
+	}
+
+	var colors *colorScheme
+	if useColor(*color, stdout) && !*jsonOutput {
+		colors = parseColors(os.Getenv("GREP_COLORS"))
+	}
+
+	// -C задает контекст с обеих сторон, явные -A и -B имеют приоритет
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	if !explicit["B"] {
+		*before = *context
+	}
+	if !explicit["A"] {
+		*after = *context
+	}
+	// С -o строки контекста не выводятся
+	if *onlyMatching {
+		*before, *after = 0, 0
+	}
+
+	g := &grepper{
+		m: m,
+		opts: searchOptions{
+			before: *before, after: *after, invert: *invert, count: *count, maxCount: max(*maxCount, 0),
+			lineNum: *lineNum, byteOffset: *byteOffset, onlyMatching: *onlyMatching, colors: colors,
+			json: report, lineBuffered: *lineBuffered || isTerminal(stdout),
+		},
+		// -m 0 не выбирает ни одной строки, файлы даже не читаются
+		selectNone: *maxCount == 0,
+		// Имена файлов выводятся, если файлов может быть несколько
+		showNames:       (len(args) > 1 || isRecursive || *withName) && !*noName,
+		listMatching:    *listMatching,
+		listNonMatching: *listNonMatching,
+		binaryFiles:     *binaryFiles,
+		hideNames:       *noName,
+		searchZip:       searchZip,
+		enc:             enc,
+		quiet:           *quiet,
+		suppressErrors:  *suppressErrors,
+		stdin:           stdin,
+		stdout:          stdout,
+		stderr:          stderr,
+	}
+
+	walkOpts := walkOptions{
+		recursive: isRecursive, followSymlinks: *dereference,
+		include: include, exclude: exclude, excludeDir: excludeDir,
+		gitignore: !*noIgnore,
+	}
+	g.searchFiles(args, walkOpts, *jobs)
+
+	if report != nil {
+		report.writeSummary(stdout)
+	}
+
+	// С -q найденное совпадение важнее ошибок в других файлах
+	switch {
+	case g.matched.Load() && (*quiet || !g.failed.Load()):
+		return exitMatch
+	case g.failed.Load():
+		return exitError
+	}
+	return exitNoMatch
+}
+
+// expandColorFlag заменяет --color без значения на --color=auto, как в GNU grep:
+// пакет flag не поддерживает необязательные значения
+func expandColorFlag(args []string) []string {
+	result := make([]string, len(args))
+	for i, arg := range args {
+		switch arg {
+		case "--color", "-color", "--colour", "-colour":
+			arg = "--color=auto"
+		case "--":
+			copy(result[i:], args[i:])
+			return result
+		}
+		result[i] = arg
+	}
+	return result
+}
+
+// grepper ищет в файлах и выводит результаты
+type grepper struct {
+	m    grep.Matcher
+	opts searchOptions
+
+	showNames       bool              // Выводить имя файла перед строками
+	listMatching    bool              // Выводить только имена файлов с совпадениями (-l)
+	listNonMatching bool              // Выводить только имена файлов без совпадений (-L)
+	binaryFiles     string            // Обработка двоичных файлов: binary, without-match, text
+	selectNone      bool              // Не выбирать строки (-m 0)
+	hideNames       bool              // Не выводить имена файлов, даже для содержимого архивов (-h)
+	searchZip       bool              // Распаковывать сжатые файлы и искать в архивах (-z)
+	enc             encoding.Encoding // Кодировка входных данных; nil — UTF-8
+	quiet           bool              // Ничего не выводить, остановиться на первом совпадении (-q)
+	suppressErrors  bool              // Не выводить ошибки чтения файлов (-s)
+
+	matched atomic.Bool // Хотя бы в одном файле выбрана строка
+	failed  atomic.Bool // Был файл, который не удалось прочитать
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// searchFile ищет в файле path и выводит результат в stdout, ошибки — в stderr
+// ("-" — стандартный ввод)
+func (g *grepper) searchFile(path string, stdout, stderr io.Writer) {
+	// С -q после первого совпадения остальные файлы не нужны
+	if g.quiet && g.matched.Load() {
+		return
+	}
+
+	// Перекодируются уже распакованные данные, поэтому файл открывается без кодировки
+	input, err := textcli.Open(path, g.stdin, nil)
+	if err != nil {
+		g.reportError(path, err, stderr)
+		return
+	}
+	defer input.Close()
+	name := path
+	if path == textcli.StdinName {
+		name = stdinName
+	}
+
+	reader := readerPool.Get().(*bufio.Reader)
+	reader.Reset(input)
+	defer readerPool.Put(reader)
+	if g.searchZip {
+		g.searchArchive(name, input.Source, reader, stdout, stderr)
+		return
+	}
+	g.searchInput(name, g.showNames, reader, stdout, stderr)
+}
+
+// searchInput ищет в данных reader и выводит результат под именем name;
+// showName — выводить имя перед строками
+func (g *grepper) searchInput(name string, showName bool, reader *bufio.Reader, stdout, stderr io.Writer) {
+	if g.enc != nil {
+		decoded := readerPool.Get().(*bufio.Reader)
+		decoded.Reset(g.enc.NewDecoder().Reader(reader))
+		defer readerPool.Put(decoded)
+		reader = decoded
+	}
+
+	binary := g.binaryFiles != "text" && isBinary(reader)
+	// В --json нет события для двоичного файла, поэтому такие файлы пропускаются
+	if binary && (g.binaryFiles == "without-match" || g.opts.json != nil) {
+		return
+	}
+
+	opts := g.opts
+	opts.path = name
+	if showName {
+		opts.label = name
+	}
+	listing := g.listMatching || g.listNonMatching || g.quiet
+	// Строки двоичного файла не выводятся, достаточно узнать, есть ли совпадение
+	if listing || binary {
+		opts.count = true
+		if !g.opts.count || listing {
+			opts.maxCount = 1
+		}
+	}
+
+	matches := 0
+	if !g.selectNone {
+		var err error
+		matches, err = search(reader, stdout, g.m, opts)
+		if err != nil {
+			g.reportError(name, err, stderr)
+			return
+		}
+	}
+	if matches > 0 {
+		g.matched.Store(true)
+	}
+
+	colors := g.opts.colors
+	if colors == nil {
+		colors = &colorScheme{}
+	}
+	label := colors.paint(colors.fileName, name)
+
+	switch {
+	case g.quiet:
+	case g.listMatching:
+		if matches > 0 {
+			fmt.Fprintln(stdout, label)
+		}
+	case g.listNonMatching:
+		if matches == 0 {
+			fmt.Fprintln(stdout, label)
+		}
+	case g.opts.count:
+		// Для -c выводится только число выбранных строк
+		if opts.label != "" {
+			fmt.Fprintf(stdout, "%s%s", label, colors.paint(colors.separator, ":"))
+		}
+		fmt.Fprintln(stdout, matches)
+	case binary && matches > 0:
+		fmt.Fprintf(stdout, "Binary file %s matches\n", name)
+	}
+}
+
+// reportError выводит ошибку доступа к файлу в stderr (кроме -s) и запоминает ее
+// для кода завершения, поиск продолжается
+func (g *grepper) reportError(path string, err error, stderr io.Writer) {
+	g.failed.Store(true)
+	if !g.suppressErrors {
+		textcli.Fail(stderr, "grep", textcli.DescribeError(path, err))
+	}
+}
+
+// patternList накапливает шаблоны из повторяющегося флага -e
+type patternList []string
+
+// String реализует flag.Value
+func (p *patternList) String() string {
+	return strings.Join(*p, "\n")
+}
+
+// Set реализует flag.Value. Шаблон с переводами строк, как в GNU grep,
+// означает несколько шаблонов.
+func (p *patternList) Set(value string) error {
+	*p = append(*p, strings.Split(value, "\n")...)
+	return nil
+}
+
+// readPatterns читает шаблоны из файла, по одному на строку.
+// Пустая строка — пустой шаблон, совпадающий с любой строкой.
+func readPatterns(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Пустой файл не содержит ни одного шаблона
+	if len(data) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
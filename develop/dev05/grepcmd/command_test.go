@@ -0,0 +1,718 @@
+package grepcmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+
+	"wb-tech-l2/develop/dev05/grep"
+)
+
+func TestPatternList(t *testing.T) {
+	var patterns patternList
+	patterns.Set("foo")
+	patterns.Set("bar\nbaz")
+	if want := (patternList{"foo", "bar", "baz"}); !reflect.DeepEqual(patterns, want) {
+		t.Errorf("got %q, want %q", patterns, want)
+	}
+}
+
+func TestReadPatterns(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"foo\nbar\n", []string{"foo", "bar"}},
+		{"foo\n\nbar", []string{"foo", "", "bar"}},
+		{"", nil},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, string(rune('a'+i)))
+		if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readPatterns(path)
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("readPatterns(%q) = %q, %v, want %q", test.content, got, err, test.want)
+		}
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	input := "a\nb\nmatch1\nc\nd\ne\nf\nmatch2\ng\nmatch3\nh\ni\n"
+	tests := []struct {
+		name string
+		opts searchOptions
+		want string
+	}{
+		{"no context", searchOptions{}, "match1\nmatch2\nmatch3\n"},
+		{"line numbers", searchOptions{lineNum: true}, "3:match1\n8:match2\n10:match3\n"},
+		{"before", searchOptions{before: 2}, "a\nb\nmatch1\n--\ne\nf\nmatch2\ng\nmatch3\n"},
+		{"after", searchOptions{after: 1}, "match1\nc\n--\nmatch2\ng\nmatch3\nh\n"},
+		{"match inside after context", searchOptions{after: 3, lineNum: true},
+			"3:match1\n4-c\n5-d\n6-e\n--\n8:match2\n9-g\n10:match3\n11-h\n12-i\n"},
+		{"adjacent windows merged", searchOptions{before: 1, after: 2, lineNum: true},
+			"2-b\n3:match1\n4-c\n5-d\n--\n7-f\n8:match2\n9-g\n10:match3\n11-h\n12-i\n"},
+		{"windows touching", searchOptions{before: 2, after: 2},
+			"a\nb\nmatch1\nc\nd\ne\nf\nmatch2\ng\nmatch3\nh\ni\n"},
+		{"invert", searchOptions{invert: true, after: 1, lineNum: true},
+			"1:a\n2:b\n3-match1\n4:c\n5:d\n6:e\n7:f\n8-match2\n9:g\n10-match3\n11:h\n12:i\n"},
+		{"count prints nothing", searchOptions{count: true, before: 1}, ""},
+	}
+
+	m, err := grep.New([]string{"match"}, grep.MatchOptions{Fixed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			count, err := search(strings.NewReader(input), &out, m, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("got %q, want %q", out.String(), test.want)
+			}
+			want := 3
+			if test.opts.invert {
+				want = 9
+			}
+			if count != want {
+				t.Errorf("count = %d, want %d", count, want)
+			}
+		})
+	}
+}
+
+// makeTree создает файлы с заданным содержимым в каталоге dir
+func makeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// chdir переходит в каталог dir на время теста
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestRunFiles(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"a.txt":              "foo\nbar\n",
+		"b.go":               "package foo\n",
+		"sub/c.txt":          "no\nfoo here\n",
+		"sub/d.log":          "foo log\n",
+		"vendor/e.txt":       "foo vendor\n",
+		"ignored/f.txt":      "foo ignored\n",
+		"build.tmp":          "foo tmp\n",
+		"keep.tmp":           "foo keep\n",
+		".gitignore":         "/ignored/\n*.tmp\n!keep.tmp\n",
+		"sub/.gitignore":     "*.log\n",
+		"bin.dat":            "foo\x00bar\n",
+		".git/config":        "foo git\n",
+		"deep/x/y/nested.md": "foo deep\n",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"single file", []string{"foo", "a.txt"}, "foo\n"},
+		{"several files", []string{"-n", "foo", "a.txt", "b.go"}, "a.txt:1:foo\nb.go:1:package foo\n"},
+		{"no file names", []string{"-h", "foo", "a.txt", "b.go"}, "foo\npackage foo\n"},
+		{"force file names", []string{"-H", "foo", "a.txt"}, "a.txt:foo\n"},
+		{"recursive with gitignore", []string{"-r", "foo"},
+			"a.txt:foo\nb.go:package foo\nBinary file bin.dat matches\ndeep/x/y/nested.md:foo deep\n" +
+				"keep.tmp:foo keep\nsub/c.txt:foo here\nvendor/e.txt:foo vendor\n"},
+		{"include", []string{"-r", "--include", "*.txt", "foo", "."},
+			"a.txt:foo\nsub/c.txt:foo here\nvendor/e.txt:foo vendor\n"},
+		{"exclude and exclude-dir", []string{"-r", "--exclude", "*.txt", "--exclude-dir", "deep", "-I", "foo"},
+			"b.go:package foo\nkeep.tmp:foo keep\n"},
+		{"no ignore", []string{"-r", "-l", "--no-ignore", "--include", "*.log", "--include", "*.tmp", "foo"},
+			"build.tmp\nkeep.tmp\nsub/d.log\n"},
+		{"list matching", []string{"-r", "-l", "--include", "*.txt", "foo"}, "a.txt\nsub/c.txt\nvendor/e.txt\n"},
+		{"list non-matching", []string{"-L", "bar", "a.txt", "b.go", "sub/c.txt"}, "b.go\nsub/c.txt\n"},
+		{"binary as text", []string{"-a", "-c", "foo", "bin.dat"}, "1\n"},
+		{"binary count", []string{"-c", "o", "bin.dat"}, "1\n"},
+		{"subdirectory context", []string{"-r", "-n", "-B", "1", "here", "sub"}, "sub/c.txt-1-no\nsub/c.txt:2:foo here\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			Run(test.args, strings.NewReader(""), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q (stderr %q)", stdout.String(), test.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{"a.txt": "foo\n"})
+	chdir(t, dir)
+
+	var stdout, stderr bytes.Buffer
+	Run([]string{"foo", "missing.txt", ".", "a.txt"}, strings.NewReader(""), &stdout, &stderr)
+	if want := "a.txt:foo\n"; stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+	for _, want := range []string{"grep: missing.txt: no such file or directory", "grep: .: это каталог"} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr %q does not contain %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestGitignoreRules(t *testing.T) {
+	rules := parseGitignore("# comment\n*.o\n/build/\ndocs/**/*.html\n!important.o\n\\#hash\n", "root")
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"root/main.o", false, true},
+		{"root/lib/util.o", false, true},
+		{"root/important.o", false, false},
+		{"root/build", true, true},
+		{"root/build", false, false},
+		{"root/lib/build", true, false},
+		{"root/docs/index.html", false, true},
+		{"root/docs/a/b/page.html", false, true},
+		{"root/other/index.html", false, false},
+		{"root/#hash", false, true},
+		{"other/main.o", false, false},
+	}
+	for _, test := range tests {
+		if got := isIgnored(rules, filepath.FromSlash(test.path), test.isDir); got != test.want {
+			t.Errorf("isIgnored(%q, %v) = %v, want %v", test.path, test.isDir, got, test.want)
+		}
+	}
+}
+
+func TestRecursiveSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{"real/a.txt": "foo\n"})
+	if err := os.Symlink("real", filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	// Ссылка на родительский каталог не должна зацикливать обход
+	if err := os.Symlink("..", filepath.Join(dir, "real", "loop")); err != nil {
+		t.Fatal(err)
+	}
+	chdir(t, dir)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-r", "-l", "foo"}, "real/a.txt\n"},
+		{[]string{"-R", "-l", "foo"}, "link/a.txt\n"},
+		{[]string{"-r", "-l", "foo", "link"}, "link/a.txt\n"},
+	}
+	for _, test := range tests {
+		var stdout, stderr bytes.Buffer
+		Run(test.args, strings.NewReader(""), &stdout, &stderr)
+		if stdout.String() != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, stdout.String(), test.want)
+		}
+	}
+}
+
+// makeCorpus создает files файлов по lines строк, часть строк содержит "needle"
+func makeCorpus(tb testing.TB, dir string, files, lines int) {
+	tb.Helper()
+	for i := 0; i < files; i++ {
+		var content strings.Builder
+		for j := 0; j < lines; j++ {
+			if (i+j)%97 == 0 {
+				fmt.Fprintf(&content, "line %d of file %d with needle\n", j, i)
+			} else {
+				fmt.Fprintf(&content, "line %d of file %d: lorem ipsum dolor sit amet\n", j, i)
+			}
+		}
+		path := filepath.Join(dir, fmt.Sprintf("dir%d", i%7), fmt.Sprintf("file%03d.txt", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestParallelMatchesSequential(t *testing.T) {
+	dir := t.TempDir()
+	makeCorpus(t, dir, 60, 500)
+	missing := filepath.Join(dir, "missing.txt")
+
+	for _, flags := range [][]string{{"-n"}, {"-c"}, {"-l"}, {"-C", "2"}} {
+		t.Run(strings.Join(flags, " "), func(t *testing.T) {
+			args := append(append([]string{"-r"}, flags...), "needle", dir, missing)
+
+			var seqOut, seqErr bytes.Buffer
+			Run(append([]string{"-j", "1"}, args...), strings.NewReader(""), &seqOut, &seqErr)
+			if seqOut.Len() == 0 || seqErr.Len() == 0 {
+				t.Fatalf("sequential search produced no output (stderr %q)", seqErr.String())
+			}
+
+			for _, workers := range []string{"2", "8"} {
+				var out, errOut bytes.Buffer
+				Run(append([]string{"-j", workers}, args...), strings.NewReader(""), &out, &errOut)
+				if out.String() != seqOut.String() || errOut.String() != seqErr.String() {
+					t.Errorf("output with -j %s differs from sequential", workers)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkSearch ищет по дереву из 200 файлов с заданным числом горутин
+func benchmarkSearch(b *testing.B, workers int) {
+	dir := b.TempDir()
+	makeCorpus(b, dir, 200, 5000)
+	args := []string{"-j", fmt.Sprint(workers), "-r", "-n", "needle", dir}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Run(args, strings.NewReader(""), io.Discard, io.Discard)
+	}
+}
+
+func BenchmarkSearchSequential(b *testing.B) { benchmarkSearch(b, 1) }
+
+func BenchmarkSearchParallel4(b *testing.B) { benchmarkSearch(b, 4) }
+
+func BenchmarkSearchParallelNumCPU(b *testing.B) { benchmarkSearch(b, runtime.NumCPU()) }
+
+func TestParseColors(t *testing.T) {
+	c := parseColors("ms=04:fn=:ne:unknown=1")
+	want := &colorScheme{
+		selectedMatch: "04", contextMatch: "01;31", fileName: "",
+		lineNum: "32", byteOffset: "32", separator: "36", noErase: true,
+	}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+	if got := parseColors("mt=7"); got.selectedMatch != "7" || got.contextMatch != "7" {
+		t.Errorf("mt should set ms and mc, got %+v", got)
+	}
+}
+
+func TestExpandColorFlag(t *testing.T) {
+	args := []string{"--color", "-n", "--colour=always", "--", "--color"}
+	want := []string{"--color=auto", "-n", "--colour=always", "--", "--color"}
+	if got := expandColorFlag(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRunOutputModes(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"a.txt":    "one foo\nfoo two foo\nnothing\nfoo three\nfoo four\n",
+		"crlf.txt": "x\r\nfoo\r\n",
+	})
+	chdir(t, dir)
+
+	const (
+		red  = "\033[01;31m\033[K"
+		fn   = "\033[35m\033[K"
+		ln   = "\033[32m\033[K"
+		se   = "\033[36m\033[K"
+		end  = "\033[m\033[K"
+		bold = "\033[1m\033[K"
+	)
+	tests := []struct {
+		name   string
+		args   []string
+		colors string
+		want   string
+	}{
+		{"only matching", []string{"-o", "foo", "a.txt"}, "", "foo\nfoo\nfoo\nfoo\nfoo\n"},
+		{"only matching with numbers", []string{"-o", "-n", "-e", "foo", "-e", "two", "a.txt"}, "",
+			"1:foo\n2:foo\n2:two\n2:foo\n4:foo\n5:foo\n"},
+		{"only matching inverted prints nothing", []string{"-o", "-v", "foo", "a.txt"}, "", ""},
+		{"byte offsets of lines", []string{"-b", "foo", "a.txt"}, "",
+			"0:one foo\n8:foo two foo\n28:foo three\n38:foo four\n"},
+		{"byte offsets of matches", []string{"-b", "-o", "foo", "a.txt"}, "", "4:foo\n8:foo\n16:foo\n28:foo\n38:foo\n"},
+		{"byte offsets with crlf", []string{"-b", "foo", "crlf.txt"}, "", "3:foo\r\n"},
+		{"max count", []string{"-m", "2", "foo", "a.txt"}, "", "one foo\nfoo two foo\n"},
+		{"max count with trailing context", []string{"-m", "1", "-A", "2", "-n", "foo", "a.txt"}, "",
+			"1:one foo\n2-foo two foo\n3-nothing\n"},
+		{"max count with count", []string{"-m", "3", "-c", "foo", "a.txt"}, "", "3\n"},
+		{"max count zero", []string{"-m", "0", "foo", "a.txt"}, "", ""},
+		{"color never", []string{"--color=never", "foo", "a.txt"}, "", "one foo\nfoo two foo\nfoo three\nfoo four\n"},
+		{"color always", []string{"--color=always", "-m", "2", "foo", "a.txt"}, "",
+			"one " + red + "foo" + end + "\n" + red + "foo" + end + " two " + red + "foo" + end + "\n"},
+		{"color prefixes", []string{"--color=always", "-H", "-n", "three", "a.txt"}, "",
+			fn + "a.txt" + end + se + ":" + end + ln + "4" + end + se + ":" + end + "foo " + red + "three" + end + "\n"},
+		{"color from GREP_COLORS", []string{"--color=always", "-o", "two", "a.txt"}, "ms=1", bold + "two" + end + "\n"},
+		{"color file list", []string{"--color=always", "-l", "foo", "a.txt"}, "fn=1", bold + "a.txt" + end + "\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GREP_COLORS", test.colors)
+			var stdout, stderr bytes.Buffer
+			Run(test.args, strings.NewReader(""), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q (stderr %q)", stdout.String(), test.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"a.txt":   "one foo\nbar\nfoo foo\n",
+		"b.txt":   "nothing\n",
+		"bin.dat": "foo\x00\n",
+		"raw.txt": "foo \xff\n",
+	})
+	chdir(t, dir)
+
+	type event struct {
+		Type string `json:"type"`
+		Data struct {
+			Path       jsonText       `json:"path"`
+			Lines      jsonText       `json:"lines"`
+			LineNumber int            `json:"line_number"`
+			Offset     int64          `json:"absolute_offset"`
+			Submatches []jsonSubmatch `json:"submatches"`
+			Stats      jsonStats      `json:"stats"`
+		} `json:"data"`
+	}
+	decode := func(t *testing.T, out string) []event {
+		var events []event
+		for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+			var e event
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("invalid JSON line %q: %v", line, err)
+			}
+			events = append(events, e)
+		}
+		return events
+	}
+
+	t.Run("events", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		Run([]string{"--json", "-A", "1", "-j", "1", "foo", "a.txt", "b.txt", "bin.dat"}, strings.NewReader(""), &stdout, &stderr)
+		events := decode(t, stdout.String())
+
+		var types []string
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		// Для b.txt без совпадений и двоичного файла событий нет
+		wantTypes := []string{"begin", "match", "context", "match", "end", "summary"}
+		if !reflect.DeepEqual(types, wantTypes) {
+			t.Fatalf("got events %v, want %v", types, wantTypes)
+		}
+
+		match := events[3].Data
+		if match.Path.Text != "a.txt" || match.Lines.Text != "foo foo\n" || match.LineNumber != 3 || match.Offset != 12 {
+			t.Errorf("unexpected match data %+v", match)
+		}
+		wantSubmatches := []jsonSubmatch{
+			{Match: jsonText{Text: "foo"}, Start: 0, End: 3},
+			{Match: jsonText{Text: "foo"}, Start: 4, End: 7},
+		}
+		if !reflect.DeepEqual(match.Submatches, wantSubmatches) {
+			t.Errorf("got submatches %+v, want %+v", match.Submatches, wantSubmatches)
+		}
+		if context := events[2].Data; context.Lines.Text != "bar\n" || len(context.Submatches) != 0 {
+			t.Errorf("unexpected context data %+v", context)
+		}
+
+		end := events[4].Data.Stats
+		if end.Searches != 1 || end.SearchesWithMatch != 1 || end.MatchedLines != 2 || end.Matches != 3 || end.BytesSearched != 20 {
+			t.Errorf("unexpected end stats %+v", end)
+		}
+		summary := events[5].Data.Stats
+		if summary.Searches != 2 || summary.SearchesWithMatch != 1 || summary.MatchedLines != 2 || summary.BytesSearched != 28 {
+			t.Errorf("unexpected summary stats %+v", summary)
+		}
+		if summary.BytesPrinted != int64(stdout.Len()-len(strings.SplitAfter(stdout.String(), "\n")[5])) {
+			t.Errorf("summary bytes_printed %d does not match output", summary.BytesPrinted)
+		}
+	})
+
+	t.Run("invalid UTF-8 as base64", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		Run([]string{"--json", "foo", "raw.txt"}, strings.NewReader(""), &stdout, &stderr)
+		events := decode(t, stdout.String())
+		if len(events) != 4 || events[1].Data.Lines.Bytes != base64.StdEncoding.EncodeToString([]byte("foo \xff\n")) {
+			t.Errorf("unexpected events %+v", events)
+		}
+	})
+
+	t.Run("incompatible flags", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		Run([]string{"--json", "-c", "foo", "a.txt"}, strings.NewReader(""), &stdout, &stderr)
+		if stdout.Len() != 0 || !strings.Contains(stderr.String(), "--json") {
+			t.Errorf("got stdout %q, stderr %q", stdout.String(), stderr.String())
+		}
+	})
+}
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"a.txt": "foo\nbar\nfoo bar\n",
+		"b.txt": "baz\n",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       string
+		wantCode   int
+		wantStderr bool
+	}{
+		{"stdin by default", []string{"foo"}, "x\nfoo\n", "foo\n", exitMatch, false},
+		{"stdin as dash", []string{"-H", "bar", "-", "b.txt"}, "bar\n", "(standard input):bar\n", exitMatch, false},
+		{"no match", []string{"qux", "a.txt"}, "", "", exitNoMatch, false},
+		{"missing file", []string{"foo", "missing.txt"}, "", "", exitError, true},
+		{"error wins over match", []string{"foo", "missing.txt", "a.txt"}, "", "a.txt:foo\na.txt:foo bar\n", exitError, true},
+		{"quiet", []string{"-q", "foo", "a.txt"}, "", "", exitMatch, false},
+		{"quiet no match", []string{"-q", "qux", "a.txt"}, "", "", exitNoMatch, false},
+		{"quiet match wins over error", []string{"-q", "foo", "missing.txt", "a.txt"}, "", "", exitMatch, true},
+		{"suppress errors", []string{"-s", "foo", "missing.txt"}, "", "", exitError, false},
+		{"count per file", []string{"-c", "foo", "a.txt", "b.txt"}, "", "a.txt:2\nb.txt:0\n", exitMatch, false},
+		{"count stdin", []string{"-c", "-v", "foo"}, "foo\nx\ny\n", "2\n", exitMatch, false},
+		{"count zero", []string{"-c", "foo", "b.txt"}, "", "0\n", exitNoMatch, false},
+		{"no pattern", []string{}, "", "", exitError, true},
+		{"invalid pattern", []string{"(", "a.txt"}, "", "", exitError, true},
+		{"unknown flag", []string{"--bogus", "foo"}, "", "", exitError, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(append([]string{"-j", "1"}, test.args...), strings.NewReader(test.stdin), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q (stderr %q)", stdout.String(), test.want, stderr.String())
+			}
+			if code != test.wantCode {
+				t.Errorf("got exit code %d, want %d", code, test.wantCode)
+			}
+			if (stderr.Len() > 0) != test.wantStderr {
+				t.Errorf("unexpected stderr %q", stderr.String())
+			}
+		})
+	}
+}
+
+// compressed сжимает data в формате format: gz, xz или zst
+func compressed(t *testing.T, format, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch format {
+	case "gz":
+		w = gzip.NewWriter(&buf)
+	case "xz":
+		w, err = xz.NewWriter(&buf)
+	case "zst":
+		w, err = zstd.NewWriter(&buf)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// zipArchive создает архив zip с файлами files в порядке names
+func zipArchive(t *testing.T, names []string, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, files[name])
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// tarArchive создает архив tar с файлами files в порядке names
+func tarArchive(t *testing.T, names []string, files map[string]string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for _, name := range names {
+		tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[name]))})
+		io.WriteString(tw, files[name])
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRunSearchZip(t *testing.T) {
+	// printf 'one\nfoo bzip\n' | bzip2: в стандартной библиотеке нет сжатия bzip2
+	bzip2Data := "BZh91AY&SY\xb3\xeb\x8f%\x00\x00\x04\xd1\x80\x00\x10@\x00\x13!\xc0\x10 \x001\x000 \x03\xd2PY\x8a\xdaD\xbc]\xc9\x14\xe1BB\xcf\xae<\x94"
+
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"plain.log":   "foo plain\n",
+		"app.log.gz":  compressed(t, "gz", "one\nfoo gzip\n"),
+		"app.log.bz2": bzip2Data,
+		"app.log.xz":  compressed(t, "xz", "foo xz\n"),
+		"app.log.zst": compressed(t, "zst", "one\ntwo\nfoo zstd\n"),
+		"logs.zip": zipArchive(t, []string{"dir/a.txt", "b.log.gz"}, map[string]string{
+			"dir/a.txt": "bar\nfoo zip\n",
+			"b.log.gz":  compressed(t, "gz", "foo nested\n"),
+		}),
+		"logs.tar.gz": compressed(t, "gz", tarArchive(t, []string{"dir/x.txt", "dir/y.txt"}, map[string]string{
+			"dir/x.txt": "foo tar\n",
+			"dir/y.txt": "nothing\n",
+		})),
+		"corrupt.gz":  "\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xffnot deflate foo",
+		"gzip-like":   "\x1f\x8bnot gzip foo\n",
+		"bzip2-like":  "BZhello foo\n",
+		"bzip2-level": "BZh9 foo\n",
+		"xz-like":     "\xfd7zXZ\x00 some foo text\n",
+	})
+	chdir(t, dir)
+
+	tests := []struct {
+		name     string
+		args     []string
+		stdin    string
+		want     string
+		wantCode int
+	}{
+		{"gzip", []string{"-z", "foo", "app.log.gz"}, "", "foo gzip\n", exitMatch},
+		{"bzip2", []string{"-z", "-n", "foo", "app.log.bz2"}, "", "2:foo bzip\n", exitMatch},
+		{"xz", []string{"--search-zip", "foo", "app.log.xz"}, "", "foo xz\n", exitMatch},
+		{"zstd", []string{"-z", "-n", "foo", "app.log.zst"}, "", "3:foo zstd\n", exitMatch},
+		{"plain file", []string{"-z", "foo", "plain.log"}, "", "foo plain\n", exitMatch},
+		{"several files", []string{"-z", "foo", "plain.log", "app.log.gz"}, "",
+			"plain.log:foo plain\napp.log.gz:foo gzip\n", exitMatch},
+		{"zip entries", []string{"-z", "-n", "foo", "logs.zip"}, "",
+			"logs.zip:dir/a.txt:2:foo zip\nlogs.zip:b.log.gz:1:foo nested\n", exitMatch},
+		{"compressed tar", []string{"-z", "foo", "logs.tar.gz"}, "", "logs.tar.gz:dir/x.txt:foo tar\n", exitMatch},
+		{"archive without names", []string{"-z", "-h", "foo", "logs.tar.gz"}, "", "foo tar\n", exitMatch},
+		{"archive count", []string{"-z", "-c", "foo", "logs.tar.gz"}, "",
+			"logs.tar.gz:dir/x.txt:1\nlogs.tar.gz:dir/y.txt:0\n", exitMatch},
+		{"zip from stdin", []string{"-z", "nested", "-"}, zipArchive(t, []string{"c.txt"}, map[string]string{"c.txt": "nested\n"}),
+			"(standard input):c.txt:nested\n", exitMatch},
+		{"corrupt gzip", []string{"-z", "foo", "corrupt.gz"}, "", "", exitError},
+		{"gzip magic in text", []string{"-z", "-a", "foo", "gzip-like"}, "", "\x1f\x8bnot gzip foo\n", exitMatch},
+		{"bzip2 magic in text", []string{"-z", "foo", "bzip2-like"}, "", "BZhello foo\n", exitMatch},
+		{"bzip2 level in text", []string{"-z", "foo", "bzip2-level"}, "", "BZh9 foo\n", exitMatch},
+		{"xz magic in text", []string{"-z", "-a", "foo", "xz-like"}, "", "\xfd7zXZ\x00 some foo text\n", exitMatch},
+		{"no match", []string{"-z", "absent", "app.log.gz", "logs.zip"}, "", "", exitNoMatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(append([]string{"-j", "1"}, test.args...), strings.NewReader(test.stdin), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q (stderr %q)", stdout.String(), test.want, stderr.String())
+			}
+			if code != test.wantCode {
+				t.Errorf("got exit code %d, want %d", code, test.wantCode)
+			}
+		})
+	}
+}
+
+// chanWriter передает каждую запись в канал, чтобы тест мог дождаться вывода
+type chanWriter chan string
+
+// Write реализует io.Writer
+func (w chanWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+// TestRunLineBuffered проверяет, что найденная строка выводится сразу,
+// пока вход еще открыт (tail -f | grep)
+func TestRunLineBuffered(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"line buffered", []string{"-j", "1", "--line-buffered", "foo"}},
+		{"line buffered json", []string{"-j", "1", "--line-buffered", "--json", "foo"}},
+		{"stdin with parallel workers", []string{"-j", "4", "--line-buffered", "foo"}},
+		{"stdin among files with parallel workers", []string{"-j", "4", "--line-buffered", "foo", "-", os.DevNull}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			out := make(chanWriter, 16)
+			done := make(chan int, 1)
+			go func() { done <- Run(test.args, pr, out, io.Discard) }()
+
+			if _, err := io.WriteString(pw, "bar\nfoo\n"); err != nil {
+				t.Fatal(err)
+			}
+			select {
+			case got := <-out:
+				if !strings.Contains(got, "foo") {
+					t.Errorf("got %q, want the matched line", got)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no output while the input is still open")
+			}
+
+			pw.Close()
+			if code := <-done; code != exitMatch {
+				t.Errorf("got exit code %d, want %d", code, exitMatch)
+			}
+		})
+	}
+}
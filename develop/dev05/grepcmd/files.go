@@ -0,0 +1,236 @@
+package grepcmd
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// walkOptions — параметры выбора файлов для поиска
+type walkOptions struct {
+	recursive      bool     // Обходить каталоги рекурсивно (-r)
+	followSymlinks bool     // Переходить по всем символическим ссылкам (-R)
+	include        []string // Искать только в файлах, имена которых подходят под шаблоны (--include)
+	exclude        []string // Пропускать файлы, имена которых подходят под шаблоны (--exclude)
+	excludeDir     []string // Пропускать каталоги, имена которых подходят под шаблоны (--exclude-dir)
+	gitignore      bool     // Учитывать файлы .gitignore при рекурсивном обходе
+}
+
+// errIsDirectory сообщает, что каталог указан без -r
+var errIsDirectory = errors.New("это каталог")
+
+// walkFiles вызывает visit для каждого файла из paths в порядке аргументов,
+// файлы внутри каталога — в порядке имен. Ошибки доступа передаются в onError,
+// после чего обход продолжается.
+func walkFiles(paths []string, opts walkOptions, visit func(path string), onError func(path string, err error)) {
+	w := &walker{opts: opts, visit: visit, onError: onError, visited: make(map[string]bool)}
+	for _, p := range paths {
+		// "-" — стандартный ввод, он не проверяется и не фильтруется
+		if p == "-" {
+			visit(p)
+			continue
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			onError(p, err)
+			continue
+		}
+		if !info.IsDir() {
+			if w.fileIncluded(p) {
+				visit(p)
+			}
+			continue
+		}
+		if !opts.recursive {
+			onError(p, errIsDirectory)
+			continue
+		}
+		// Символические ссылки из командной строки раскрываются и при -r
+		w.walkDir(p, nil)
+	}
+}
+
+// walker обходит дерево каталогов
+type walker struct {
+	opts    walkOptions
+	visit   func(path string)
+	onError func(path string, err error)
+	visited map[string]bool // Реальные пути пройденных каталогов, защищают от циклов при -R
+}
+
+// walkDir обходит каталог dir. rules — правила .gitignore родительских каталогов.
+func (w *walker) walkDir(dir string, rules []ignoreRule) {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if w.visited[real] {
+			return
+		}
+		w.visited[real] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		w.onError(dir, err)
+		return
+	}
+
+	if w.opts.gitignore {
+		data, err := os.ReadFile(filepath.Join(dir, ".gitignore"))
+		if err == nil {
+			// Правила вложенного каталога проверяются после родительских
+			rules = append(rules[:len(rules):len(rules)], parseGitignore(string(data), dir)...)
+		}
+	}
+
+	for _, entry := range entries {
+		p := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+
+		if entry.Type()&os.ModeSymlink != 0 {
+			// При -r ссылки внутри каталогов пропускаются, при -R — раскрываются
+			if !w.opts.followSymlinks {
+				continue
+			}
+			info, err := os.Stat(p)
+			if err != nil {
+				w.onError(p, err)
+				continue
+			}
+			isDir = info.IsDir()
+		}
+
+		if w.opts.gitignore && (isDir && entry.Name() == ".git" || isIgnored(rules, p, isDir)) {
+			continue
+		}
+		if isDir {
+			if !matchesAny(w.opts.excludeDir, entry.Name()) {
+				w.walkDir(p, rules)
+			}
+			continue
+		}
+		if entry.Type().IsRegular() || entry.Type()&os.ModeSymlink != 0 {
+			if w.fileIncluded(p) {
+				w.visit(p)
+			}
+		}
+	}
+}
+
+// fileIncluded проверяет имя файла по шаблонам --include и --exclude
+func (w *walker) fileIncluded(p string) bool {
+	name := filepath.Base(p)
+	if len(w.opts.include) > 0 && !matchesAny(w.opts.include, name) {
+		return false
+	}
+	return !matchesAny(w.opts.exclude, name)
+}
+
+// matchesAny сообщает, что имя подходит хотя бы под один шаблон
+func matchesAny(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule — правило из файла .gitignore
+type ignoreRule struct {
+	base     string   // Каталог файла .gitignore, относительно которого действует правило
+	segments []string // Шаблон, разбитый по "/"
+	anchored bool     // Шаблон сопоставляется с путем от base, а не с именем файла
+	negate   bool     // Правило начинается с "!" и отменяет игнорирование
+	dirOnly  bool     // Шаблон оканчивается на "/" и относится только к каталогам
+}
+
+// parseGitignore разбирает содержимое файла .gitignore из каталога base
+func parseGitignore(data, base string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		// Хвостовые пробелы не значимы, если не экранированы
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if line[0] == '!' {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// Шаблон со слешем в начале или в середине привязан к каталогу .gitignore
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.segments = strings.Split(line, "/")
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// isIgnored сообщает, что путь исключен правилами .gitignore.
+// Как и в git, решает последнее подходящее правило.
+func isIgnored(rules []ignoreRule, p string, isDir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.match(p, isDir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// match сообщает, что правило подходит к пути p
+func (r ignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	rel, err := filepath.Rel(r.base, p)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if !r.anchored {
+		// Шаблон без слеша сравнивается с именем на любой глубине
+		return matchSegments(r.segments, parts[len(parts)-1:])
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// matchSegments сопоставляет путь, разбитый на части, с шаблоном;
+// "**" соответствует любому числу каталогов
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
@@ -0,0 +1,342 @@
+package grepcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// flagCorpus — стандартный ввод для тестов отдельных флагов
+const flagCorpus = "alpha one\nBeta two\ngamma three\nalphabet\ndelta alpha\n"
+
+// flagCase — аргументы grep и ожидаемый результат
+type flagCase struct {
+	args []string
+	want string
+	code int
+}
+
+// checkFlag запускает grep для каждого случая, подавая flagCorpus на стандартный ввод
+func checkFlag(t *testing.T, cases ...flagCase) {
+	t.Helper()
+	for _, c := range cases {
+		var stdout, stderr bytes.Buffer
+		code := Run(append([]string{"-j", "1"}, c.args...), strings.NewReader(flagCorpus), &stdout, &stderr)
+		if stdout.String() != c.want || code != c.code {
+			t.Errorf("grep %q: got %q, exit %d, want %q, exit %d (stderr %q)",
+				c.args, stdout.String(), code, c.want, c.code, stderr.String())
+		}
+	}
+}
+
+// flagTree создает дерево файлов для флагов выбора файлов и переходит в него
+func flagTree(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{
+		"logs/app.log":      "alpha log\n",
+		"logs/skip/old.log": "alpha old\n",
+		"notes.txt":         "alpha note\nbeta\n",
+		"empty.txt":         "nothing\n",
+		".gitignore":        "ignored.txt\n",
+		"ignored.txt":       "alpha ignored\n",
+		"bin.dat":           "alpha\x00\n",
+	})
+	if err := os.Symlink("logs", filepath.Join(dir, "link")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+	chdir(t, dir)
+}
+
+func TestFlagAfterContext(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-A", "1", "gamma"}, "gamma three\nalphabet\n", exitMatch},
+		flagCase{[]string{"-A", "1", "-n", "Beta"}, "2:Beta two\n3-gamma three\n", exitMatch},
+	)
+}
+
+func TestFlagBeforeContext(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-B", "1", "gamma"}, "Beta two\ngamma three\n", exitMatch},
+		flagCase{[]string{"-B", "1", "-n", "one"}, "1:alpha one\n", exitMatch},
+	)
+}
+
+func TestFlagContext(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-C", "1", "gamma"}, "Beta two\ngamma three\nalphabet\n", exitMatch},
+		flagCase{[]string{"-C", "1", "-A", "0", "gamma"}, "Beta two\ngamma three\n", exitMatch},
+	)
+}
+
+func TestFlagCount(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-c", "alpha"}, "3\n", exitMatch},
+		flagCase{[]string{"-c", "zeta"}, "0\n", exitNoMatch},
+	)
+}
+
+func TestFlagIgnoreCase(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"beta"}, "", exitNoMatch},
+		flagCase{[]string{"-i", "beta"}, "Beta two\n", exitMatch},
+		flagCase{[]string{"-i", "-F", "-e", "BETA", "-e", "GAMMA"}, "Beta two\ngamma three\n", exitMatch},
+	)
+}
+
+func TestFlagInvert(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-v", "alpha"}, "Beta two\ngamma three\n", exitMatch},
+		flagCase{[]string{"-v", "a"}, "", exitNoMatch},
+	)
+}
+
+func TestFlagFixed(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"a.p"}, "alpha one\nalphabet\ndelta alpha\n", exitMatch},
+		flagCase{[]string{"-F", "a.p"}, "", exitNoMatch},
+		flagCase{[]string{"-F", "-e", "two", "-e", "bet"}, "Beta two\nalphabet\n", exitMatch},
+	)
+}
+
+func TestFlagExtended(t *testing.T) {
+	checkFlag(t,
+		// Без -E выбирается первая подходящая альтернатива, с -E — самая длинная
+		flagCase{[]string{"-o", "alpha|alphabet"}, "alpha\nalpha\nalpha\n", exitMatch},
+		flagCase{[]string{"-o", "-E", "alpha|alphabet"}, "alpha\nalphabet\nalpha\n", exitMatch},
+		flagCase{[]string{"-E", "(?:x)"}, "", exitError},
+	)
+}
+
+func TestFlagWord(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-w", "alpha"}, "alpha one\ndelta alpha\n", exitMatch},
+		flagCase{[]string{"-w", "alph"}, "", exitNoMatch},
+		// Первая альтернатива не является словом, но вторая с того же начала является
+		flagCase{[]string{"-w", "alpha|alphabet"}, "alpha one\nalphabet\ndelta alpha\n", exitMatch},
+		flagCase{[]string{"-o", "-w", "alpha|alphabet"}, "alpha\nalphabet\nalpha\n", exitMatch},
+		flagCase{[]string{"-w", "--color=always", "alph|alphabet"}, "\x1b[01;31m\x1b[Kalphabet\x1b[m\x1b[K\n", exitMatch},
+	)
+}
+
+func TestFlagLine(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-x", "alphabet"}, "alphabet\n", exitMatch},
+		flagCase{[]string{"-x", "alpha"}, "", exitNoMatch},
+	)
+}
+
+func TestFlagLineNumber(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-n", "gamma"}, "3:gamma three\n", exitMatch},
+	)
+}
+
+func TestFlagPattern(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-e", "Beta", "-e", "gamma"}, "Beta two\ngamma three\n", exitMatch},
+		// Шаблон, начинающийся с "-", передается через -e
+		flagCase{[]string{"-e", "-x"}, "", exitNoMatch},
+	)
+}
+
+func TestFlagPatternFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns")
+	if err := os.WriteFile(path, []byte("two\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkFlag(t,
+		flagCase{[]string{"-f", path}, "Beta two\ngamma three\n", exitMatch},
+		flagCase{[]string{"-f", path, "-e", "one"}, "alpha one\nBeta two\ngamma three\n", exitMatch},
+		flagCase{[]string{"-f", path + ".missing"}, "", exitError},
+	)
+}
+
+func TestFlagRecursive(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-r", "-I", "alpha"}, "logs/app.log:alpha log\nlogs/skip/old.log:alpha old\nnotes.txt:alpha note\n", exitMatch},
+		flagCase{[]string{"-r", "alpha", "logs"}, "logs/app.log:alpha log\nlogs/skip/old.log:alpha old\n", exitMatch},
+		flagCase{[]string{"alpha", "logs"}, "", exitError},
+	)
+}
+
+func TestFlagDereferenceRecursive(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-R", "--exclude-dir", "logs", "log"}, "link/app.log:alpha log\n", exitMatch},
+		flagCase{[]string{"-r", "--exclude-dir", "logs", "log"}, "", exitNoMatch},
+	)
+}
+
+func TestFlagWithFilename(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"note"}, "", exitNoMatch},
+		flagCase{[]string{"note", "notes.txt"}, "alpha note\n", exitMatch},
+		flagCase{[]string{"-H", "note", "notes.txt"}, "notes.txt:alpha note\n", exitMatch},
+		flagCase{[]string{"-H", "one"}, "(standard input):alpha one\n", exitMatch},
+	)
+}
+
+func TestFlagNoFilename(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-h", "alpha", "notes.txt", "logs/app.log"}, "alpha note\nalpha log\n", exitMatch},
+	)
+}
+
+func TestFlagFilesWithMatches(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-l", "alpha", "notes.txt", "empty.txt", "logs/app.log"}, "notes.txt\nlogs/app.log\n", exitMatch},
+	)
+}
+
+func TestFlagFilesWithoutMatch(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-L", "alpha", "notes.txt", "empty.txt"}, "empty.txt\n", exitMatch},
+	)
+}
+
+func TestFlagInclude(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-r", "--include", "*.txt", "alpha"}, "notes.txt:alpha note\n", exitMatch},
+	)
+}
+
+func TestFlagExclude(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-r", "--exclude", "*.txt", "--exclude", "*.dat", "alpha"},
+			"logs/app.log:alpha log\nlogs/skip/old.log:alpha old\n", exitMatch},
+	)
+}
+
+func TestFlagExcludeDir(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-r", "--exclude-dir", "skip", "alpha", "logs"}, "logs/app.log:alpha log\n", exitMatch},
+	)
+}
+
+func TestFlagNoIgnore(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-r", "alpha ignored"}, "", exitNoMatch},
+		flagCase{[]string{"-r", "--no-ignore", "alpha ignored"}, "ignored.txt:alpha ignored\n", exitMatch},
+	)
+}
+
+func TestFlagBinaryFiles(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"alpha", "bin.dat"}, "Binary file bin.dat matches\n", exitMatch},
+		flagCase{[]string{"--binary-files=without-match", "alpha", "bin.dat"}, "", exitNoMatch},
+		flagCase{[]string{"--binary-files=text", "-c", "alpha", "bin.dat"}, "1\n", exitMatch},
+		flagCase{[]string{"--binary-files=skip", "alpha", "bin.dat"}, "", exitError},
+	)
+}
+
+func TestFlagSkipBinary(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-I", "alpha", "bin.dat", "notes.txt"}, "notes.txt:alpha note\n", exitMatch},
+	)
+}
+
+func TestFlagText(t *testing.T) {
+	flagTree(t)
+	checkFlag(t,
+		flagCase{[]string{"-a", "-o", "alpha", "bin.dat"}, "alpha\n", exitMatch},
+	)
+}
+
+func TestFlagJobs(t *testing.T) {
+	flagTree(t)
+	for _, jobs := range []string{"1", "2", "8"} {
+		checkFlag(t,
+			flagCase{[]string{"-j", jobs, "-r", "-I", "alpha"},
+				"logs/app.log:alpha log\nlogs/skip/old.log:alpha old\nnotes.txt:alpha note\n", exitMatch},
+		)
+	}
+	checkFlag(t, flagCase{[]string{"-j", "0", "alpha"}, "", exitError})
+}
+
+func TestFlagColor(t *testing.T) {
+	t.Setenv("GREP_COLORS", "")
+	checkFlag(t,
+		flagCase{[]string{"--color=always", "two"}, "Beta \033[01;31m\033[Ktwo\033[m\033[K\n", exitMatch},
+		flagCase{[]string{"--colour=never", "two"}, "Beta two\n", exitMatch},
+		// Вывод в буфер — не терминал
+		flagCase{[]string{"--color", "two"}, "Beta two\n", exitMatch},
+		flagCase{[]string{"--color=sometimes", "two"}, "", exitError},
+	)
+}
+
+func TestFlagOnlyMatching(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-o", "[a-z]*ph[a-z]*"}, "alpha\nalphabet\nalpha\n", exitMatch},
+		flagCase{[]string{"-o", "-C", "1", "gamma"}, "gamma\n", exitMatch},
+	)
+}
+
+func TestFlagByteOffset(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-b", "gamma"}, "19:gamma three\n", exitMatch},
+		flagCase{[]string{"-b", "-o", "three"}, "25:three\n", exitMatch},
+	)
+}
+
+func TestFlagMaxCount(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-m", "2", "alpha"}, "alpha one\nalphabet\n", exitMatch},
+		flagCase{[]string{"-m", "1", "-c", "alpha"}, "1\n", exitMatch},
+		flagCase{[]string{"-m", "0", "alpha"}, "", exitNoMatch},
+	)
+}
+
+func TestFlagJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"--json", "gamma"}, strings.NewReader(flagCorpus), &stdout, &stderr)
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if code != exitMatch || len(lines) != 4 {
+		t.Fatalf("got exit %d, output %q", code, stdout.String())
+	}
+	for i, prefix := range []string{`{"type":"begin"`, `{"type":"match"`, `{"type":"end"`, `{"type":"summary"`} {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d = %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+	checkFlag(t, flagCase{[]string{"--json", "-l", "gamma"}, "", exitError})
+}
+
+func TestFlagQuiet(t *testing.T) {
+	checkFlag(t,
+		flagCase{[]string{"-q", "alpha"}, "", exitMatch},
+		flagCase{[]string{"-q", "zeta"}, "", exitNoMatch},
+		flagCase{[]string{"-q", "-c", "alpha"}, "", exitMatch},
+	)
+}
+
+func TestFlagNoMessages(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"-s", "alpha", "missing.txt", "-"}, strings.NewReader(flagCorpus), &stdout, &stderr)
+	if code != exitError || stderr.Len() != 0 || !strings.Contains(stdout.String(), "alpha one") {
+		t.Errorf("got exit %d, stdout %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestFlagSearchZip(t *testing.T) {
+	dir := t.TempDir()
+	makeTree(t, dir, map[string]string{"app.log.gz": compressed(t, "gz", flagCorpus)})
+	chdir(t, dir)
+	checkFlag(t,
+		flagCase{[]string{"-z", "gamma", "app.log.gz"}, "gamma three\n", exitMatch},
+		flagCase{[]string{"-z", "-c", "alpha", "app.log.gz"}, "3\n", exitMatch},
+	)
+}
@@ -0,0 +1,230 @@
+package grepcmd
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"wb-tech-l2/develop/dev05/grep"
+)
+
+// Вывод --json повторяет формат JSON Lines утилиты ripgrep: по одному объекту
+// {"type": ..., "data": ...} на событие. Для файла с результатами выводятся
+// begin, события match и context, затем end со статистикой; в конце — summary.
+
+// jsonEvent — одно событие вывода
+type jsonEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// jsonText — строка или, если она не в UTF-8, ее байты в base64
+type jsonText struct {
+	Text  string `json:"text,omitempty"`
+	Bytes string `json:"bytes,omitempty"`
+}
+
+// newJSONText кодирует s для вывода в JSON
+func newJSONText(s string) jsonText {
+	if utf8.ValidString(s) {
+		return jsonText{Text: s}
+	}
+	return jsonText{Bytes: base64.StdEncoding.EncodeToString([]byte(s))}
+}
+
+// jsonBegin — данные события begin
+type jsonBegin struct {
+	Path jsonText `json:"path"`
+}
+
+// jsonLine — данные событий match и context
+type jsonLine struct {
+	Path           jsonText       `json:"path"`
+	Lines          jsonText       `json:"lines"`
+	LineNumber     int            `json:"line_number"`
+	AbsoluteOffset int64          `json:"absolute_offset"`
+	Submatches     []jsonSubmatch `json:"submatches"`
+}
+
+// jsonSubmatch — совпадение внутри строки; start и end — смещения в байтах от начала строки
+type jsonSubmatch struct {
+	Match jsonText `json:"match"`
+	Start int      `json:"start"`
+	End   int      `json:"end"`
+}
+
+// jsonEnd — данные события end
+type jsonEnd struct {
+	Path         jsonText  `json:"path"`
+	BinaryOffset *int64    `json:"binary_offset"`
+	Stats        jsonStats `json:"stats"`
+}
+
+// jsonSummary — данные итогового события summary
+type jsonSummary struct {
+	ElapsedTotal jsonDuration `json:"elapsed_total"`
+	Stats        jsonStats    `json:"stats"`
+}
+
+// jsonStats — статистика поиска по файлу или по всем файлам
+type jsonStats struct {
+	Elapsed           jsonDuration `json:"elapsed"`
+	Searches          int          `json:"searches"`
+	SearchesWithMatch int          `json:"searches_with_match"`
+	BytesSearched     int64        `json:"bytes_searched"`
+	BytesPrinted      int64        `json:"bytes_printed"`
+	MatchedLines      int          `json:"matched_lines"`
+	Matches           int          `json:"matches"`
+}
+
+// add прибавляет статистику другого поиска
+func (s *jsonStats) add(other jsonStats) {
+	s.Elapsed = newJSONDuration(s.Elapsed.duration + other.Elapsed.duration)
+	s.Searches += other.Searches
+	s.SearchesWithMatch += other.SearchesWithMatch
+	s.BytesSearched += other.BytesSearched
+	s.BytesPrinted += other.BytesPrinted
+	s.MatchedLines += other.MatchedLines
+	s.Matches += other.Matches
+}
+
+// jsonDuration — длительность в формате ripgrep
+type jsonDuration struct {
+	Secs  int64  `json:"secs"`
+	Nanos int    `json:"nanos"`
+	Human string `json:"human"`
+
+	duration time.Duration
+}
+
+// newJSONDuration переводит длительность в формат вывода
+func newJSONDuration(d time.Duration) jsonDuration {
+	return jsonDuration{
+		Secs:     int64(d / time.Second),
+		Nanos:    int(d % time.Second),
+		Human:    fmt.Sprintf("%.6fs", d.Seconds()),
+		duration: d,
+	}
+}
+
+// jsonReport накапливает статистику всех файлов для события summary.
+// Файлы могут обрабатываться параллельно, поэтому доступ защищен мьютексом.
+type jsonReport struct {
+	mu    sync.Mutex
+	start time.Time
+	stats jsonStats
+}
+
+// newJSONReport начинает отсчет общего времени поиска
+func newJSONReport() *jsonReport {
+	return &jsonReport{start: time.Now()}
+}
+
+// add учитывает статистику одного файла
+func (r *jsonReport) add(stats jsonStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats.add(stats)
+}
+
+// writeSummary выводит итоговое событие summary
+func (r *jsonReport) writeSummary(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.Marshal(jsonEvent{Type: "summary", Data: jsonSummary{
+		ElapsedTotal: newJSONDuration(time.Since(r.start)),
+		Stats:        r.stats,
+	}})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// jsonPrinter выводит найденные строки одного файла событиями JSON
+type jsonPrinter struct {
+	out    *bufio.Writer
+	path   jsonText
+	report *jsonReport
+	start  time.Time
+	began  bool // Событие begin уже выведено
+	stats  jsonStats
+}
+
+// newJSONPrinter создает вывод событий для файла path
+func newJSONPrinter(out *bufio.Writer, path string, report *jsonReport) *jsonPrinter {
+	return &jsonPrinter{out: out, path: newJSONText(path), report: report, start: time.Now()}
+}
+
+// emit выводит одно событие и учитывает выведенные байты
+func (p *jsonPrinter) emit(eventType string, data interface{}) {
+	encoded, err := json.Marshal(jsonEvent{Type: eventType, Data: data})
+	if err != nil {
+		// Все типы событий сериализуемы, ошибка означает ошибку в программе
+		panic(err)
+	}
+	p.out.Write(encoded)
+	p.out.WriteByte('\n')
+	p.stats.BytesPrinted += int64(len(encoded)) + 1
+}
+
+// Line реализует grep.Sink: выводит событие match для выбранной строки
+// и context для строки контекста
+func (p *jsonPrinter) Line(line grep.Line) error {
+	// Событие begin выводится только для файлов, в которых что-то нашлось
+	if !p.began {
+		p.emit("begin", jsonBegin{Path: p.path})
+		p.began = true
+	}
+
+	submatches := make([]jsonSubmatch, 0, len(line.Matches))
+	for _, loc := range line.Matches {
+		submatches = append(submatches, jsonSubmatch{
+			Match: newJSONText(line.Text[loc[0]:loc[1]]), Start: loc[0], End: loc[1],
+		})
+	}
+
+	eventType := "context"
+	if line.Selected {
+		eventType = "match"
+		p.stats.MatchedLines++
+		p.stats.Matches += len(line.Matches)
+	}
+	p.emit(eventType, jsonLine{
+		Path:           p.path,
+		Lines:          newJSONText(line.Text + "\n"),
+		LineNumber:     line.Number,
+		AbsoluteOffset: line.Offset,
+		Submatches:     submatches,
+	})
+	return nil
+}
+
+// Separator реализует grep.Sink и ничего не выводит: в JSON группы
+// различаются по номерам строк
+func (p *jsonPrinter) Separator() error {
+	return nil
+}
+
+// Finish реализует grep.Sink: выводит событие end и передает статистику
+// файла в итоговую сводку
+func (p *jsonPrinter) Finish(stats grep.Stats) error {
+	p.stats.Elapsed = newJSONDuration(time.Since(p.start))
+	p.stats.Searches = 1
+	p.stats.BytesSearched = stats.BytesSearched
+	if p.stats.MatchedLines > 0 {
+		p.stats.SearchesWithMatch = 1
+	}
+	// В end попадает статистика без него самого, в сводку — вместе с ним
+	if p.began {
+		p.emit("end", jsonEnd{Path: p.path, Stats: p.stats})
+	}
+	p.report.add(p.stats)
+	return nil
+}
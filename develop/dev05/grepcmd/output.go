@@ -0,0 +1,225 @@
+package grepcmd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"wb-tech-l2/develop/dev05/grep"
+)
+
+// defaultColors — цвета GNU grep по умолчанию в формате GREP_COLORS
+const defaultColors = "ms=01;31:mc=01;31:sl=:cx=:fn=35:ln=32:bn=32:se=36"
+
+// colorScheme — коды SGR для элементов вывода, как в переменной GREP_COLORS
+type colorScheme struct {
+	selectedMatch string // ms: совпадение в выбранной строке
+	contextMatch  string // mc: совпадение в строке контекста
+	selectedLine  string // sl: выбранная строка целиком
+	contextLine   string // cx: строка контекста целиком
+	fileName      string // fn: имя файла
+	lineNum       string // ln: номер строки
+	byteOffset    string // bn: смещение в байтах
+	separator     string // se: разделители ':', '-' и "--"
+	noErase       bool   // ne: не очищать конец строки последовательностью EL
+}
+
+// parseColors разбирает значение GREP_COLORS поверх цветов по умолчанию.
+// Неизвестные ключи игнорируются, как в GNU grep.
+func parseColors(spec string) *colorScheme {
+	c := &colorScheme{}
+	for _, s := range []string{defaultColors, spec} {
+		for _, item := range strings.Split(s, ":") {
+			key, value, _ := strings.Cut(item, "=")
+			switch key {
+			case "mt":
+				c.selectedMatch, c.contextMatch = value, value
+			case "ms":
+				c.selectedMatch = value
+			case "mc":
+				c.contextMatch = value
+			case "sl":
+				c.selectedLine = value
+			case "cx":
+				c.contextLine = value
+			case "fn":
+				c.fileName = value
+			case "ln":
+				c.lineNum = value
+			case "bn":
+				c.byteOffset = value
+			case "se":
+				c.separator = value
+			case "ne":
+				c.noErase = true
+			}
+		}
+	}
+	return c
+}
+
+// start возвращает последовательность включения цвета code
+func (c *colorScheme) start(code string) string {
+	if c.noErase {
+		return "\033[" + code + "m"
+	}
+	return "\033[" + code + "m\033[K"
+}
+
+// end возвращает последовательность сброса цвета
+func (c *colorScheme) end() string {
+	if c.noErase {
+		return "\033[m"
+	}
+	return "\033[m\033[K"
+}
+
+// paint окрашивает s цветом code; с пустым кодом s не меняется
+func (c *colorScheme) paint(code, s string) string {
+	if code == "" {
+		return s
+	}
+	return c.start(code) + s + c.end()
+}
+
+// useColor определяет, нужен ли цвет для режима --color
+func useColor(mode string, stdout io.Writer) bool {
+	switch mode {
+	case "always":
+		return true
+	case "auto":
+		return os.Getenv("TERM") != "dumb" && isTerminal(stdout)
+	}
+	return false
+}
+
+// isTerminal сообщает, что w — терминал
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// flushingSink сбрасывает буфер вывода после каждой строки и разделителя,
+// чтобы найденные строки появлялись сразу, даже если вход еще не закончился
+// (tail -f | grep)
+type flushingSink struct {
+	grep.Sink
+	out *bufio.Writer
+}
+
+// Line реализует grep.Sink
+func (s flushingSink) Line(line grep.Line) error {
+	if err := s.Sink.Line(line); err != nil {
+		return err
+	}
+	return s.out.Flush()
+}
+
+// Separator реализует grep.Sink
+func (s flushingSink) Separator() error {
+	if err := s.Sink.Separator(); err != nil {
+		return err
+	}
+	return s.out.Flush()
+}
+
+// printer форматирует строки вывода: префиксы с именем файла, номером строки
+// и смещением, подсветку совпадений и режим -o
+type printer struct {
+	out          *bufio.Writer
+	colors       *colorScheme // Цвета; пустая схема — вывод без цвета
+	label        string       // Имя файла; пустое — без префикса
+	lineNum      bool         // Номер строки (-n)
+	byteOffset   bool         // Смещение в байтах (-b)
+	onlyMatching bool         // Только совпавшие части строки (-o)
+}
+
+// Separator реализует grep.Sink: выводит разделитель несмежных групп контекста
+func (p *printer) Separator() error {
+	p.out.WriteString(p.colors.paint(p.colors.separator, "--"))
+	return p.out.WriteByte('\n')
+}
+
+// prefix выводит имя файла, номер строки и смещение; sep — ':' для выбранных
+// строк и '-' для строк контекста
+func (p *printer) prefix(num int, offset int64, sep byte) {
+	separator := p.colors.paint(p.colors.separator, string(sep))
+	if p.label != "" {
+		p.out.WriteString(p.colors.paint(p.colors.fileName, p.label))
+		p.out.WriteString(separator)
+	}
+	if p.lineNum {
+		p.out.WriteString(p.colors.paint(p.colors.lineNum, strconv.Itoa(num)))
+		p.out.WriteString(separator)
+	}
+	if p.byteOffset {
+		p.out.WriteString(p.colors.paint(p.colors.byteOffset, strconv.FormatInt(offset, 10)))
+		p.out.WriteString(separator)
+	}
+}
+
+// Line реализует grep.Sink: выводит строку с префиксом и подсветкой совпадений
+func (p *printer) Line(line grep.Line) error {
+	selected := line.Selected
+	sep := byte('-')
+	if selected {
+		sep = ':'
+	}
+
+	if p.onlyMatching {
+		// Каждое совпадение — отдельная строка со своим смещением
+		for _, loc := range line.Matches {
+			p.prefix(line.Number, line.Offset+int64(loc[0]), sep)
+			p.out.WriteString(p.colors.paint(p.matchColor(selected), line.Text[loc[0]:loc[1]]))
+			p.out.WriteByte('\n')
+		}
+		return nil
+	}
+
+	p.prefix(line.Number, line.Offset, sep)
+	lineColor := p.colors.contextLine
+	if selected {
+		lineColor = p.colors.selectedLine
+	}
+	matchColor := p.matchColor(selected)
+	if lineColor != "" {
+		p.out.WriteString(p.colors.start(lineColor))
+	}
+	pos := 0
+	for _, loc := range line.Matches {
+		if matchColor == "" {
+			break
+		}
+		p.out.WriteString(line.Text[pos:loc[0]])
+		p.out.WriteString(p.colors.paint(matchColor, line.Text[loc[0]:loc[1]]))
+		// После совпадения восстанавливаем цвет строки
+		if lineColor != "" {
+			p.out.WriteString(p.colors.start(lineColor))
+		}
+		pos = loc[1]
+	}
+	p.out.WriteString(line.Text[pos:])
+	if lineColor != "" {
+		p.out.WriteString(p.colors.end())
+	}
+	return p.out.WriteByte('\n')
+}
+
+// Finish реализует grep.Sink; текстовый вывод не подводит итогов по файлу
+func (p *printer) Finish(grep.Stats) error {
+	return nil
+}
+
+// matchColor возвращает цвет совпадения в выбранной строке или в контексте
+func (p *printer) matchColor(selected bool) string {
+	if selected {
+		return p.colors.selectedMatch
+	}
+	return p.colors.contextMatch
+}
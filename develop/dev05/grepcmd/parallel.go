@@ -0,0 +1,100 @@
+package grepcmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"sync"
+)
+
+// readBufferSize — размер буфера чтения файла: крупные блоки уменьшают
+// число системных вызовов на больших файлах
+const readBufferSize = 256 << 10
+
+// readerPool переиспользует буферы чтения между файлами
+var readerPool = sync.Pool{
+	New: func() interface{} { return bufio.NewReaderSize(nil, readBufferSize) },
+}
+
+// fileOutput — накопленный вывод поиска по одному файлу
+type fileOutput struct {
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+// searchFiles ищет во всех файлах из paths. При workers > 1 и нескольких файлах
+// они обрабатываются параллельно, но вывод каждого файла накапливается
+// и печатается целиком в порядке обхода, поэтому результат не зависит от числа
+// горутин. Один файл и стандартный ввод ищутся последовательно: их вывод
+// не накапливается и появляется сразу.
+func (g *grepper) searchFiles(paths []string, walkOpts walkOptions, workers int) {
+	if workers <= 1 || singleInput(paths, walkOpts.recursive) {
+		walkFiles(paths, walkOpts,
+			func(path string) { g.searchFile(path, g.stdout, g.stderr) },
+			func(path string, err error) { g.reportError(path, err, g.stderr) })
+		return
+	}
+
+	type job struct {
+		path   string
+		result chan<- *fileOutput
+	}
+	jobs := make(chan job)
+	// Результаты в порядке обхода; емкость очереди ограничивает,
+	// насколько обход может опередить вывод
+	pending := make(chan chan *fileOutput, workers*4)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out := &fileOutput{}
+				g.searchFile(j.path, &out.stdout, &out.stderr)
+				j.result <- out
+			}
+		}()
+	}
+
+	go func() {
+		walkFiles(paths, walkOpts,
+			func(path string) {
+				result := make(chan *fileOutput, 1)
+				pending <- result
+				jobs <- job{path: path, result: result}
+			},
+			func(path string, err error) {
+				// Ошибка обхода выводится на своем месте среди результатов
+				out := &fileOutput{}
+				g.reportError(path, err, &out.stderr)
+				result := make(chan *fileOutput, 1)
+				result <- out
+				pending <- result
+			})
+		close(jobs)
+		close(pending)
+	}()
+
+	for result := range pending {
+		out := <-result
+		g.stdout.Write(out.stdout.Bytes())
+		g.stderr.Write(out.stderr.Bytes())
+	}
+	wg.Wait()
+}
+
+// singleInput сообщает, что параллельный поиск не нужен: среди входов есть
+// стандартный ввод или вход один и это не каталог для рекурсивного обхода
+func singleInput(paths []string, recursive bool) bool {
+	for _, p := range paths {
+		if p == "-" {
+			return true
+		}
+	}
+	if len(paths) != 1 {
+		return false
+	}
+	info, err := os.Stat(paths[0])
+	return err != nil || !recursive || !info.IsDir()
+}
@@ -0,0 +1,76 @@
+package grepcmd
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"wb-tech-l2/develop/dev05/grep"
+)
+
+// binaryCheckSize — сколько байт в начале файла проверяется на признаки двоичного файла
+const binaryCheckSize = 8 << 10
+
+// isBinary сообщает, что данные похожи на двоичный файл: как и GNU grep,
+// считаем двоичными данные с нулевым байтом в начале файла. Проверяется только
+// то, что уже прочитано первым чтением: ожидание полного блока задержало бы
+// вывод, пока вход не закончится (tail -f | grep).
+func isBinary(r *bufio.Reader) bool {
+	r.Peek(1)
+	head, _ := r.Peek(min(r.Buffered(), binaryCheckSize))
+	return bytes.IndexByte(head, 0) >= 0
+}
+
+// searchOptions — параметры поиска и вывода найденных строк
+type searchOptions struct {
+	before       int          // Число строк контекста до совпадения (-B)
+	after        int          // Число строк контекста после совпадения (-A)
+	invert       bool         // Выбирать несовпадающие строки (-v)
+	count        bool         // Только подсчитать строки, ничего не выводить (-c)
+	maxCount     int          // Остановиться после стольких выбранных строк (-m); 0 — без ограничения
+	lineNum      bool         // Выводить номера строк (-n)
+	byteOffset   bool         // Выводить смещение строки или совпадения в байтах (-b)
+	onlyMatching bool         // Выводить только совпавшие части строк (-o)
+	colors       *colorScheme // Цвета вывода; nil — без цвета
+	label        string       // Префикс строк — имя файла; пустой — без префикса
+	json         *jsonReport  // Сводка вывода --json; nil — текстовый вывод
+	path         string       // Имя файла в событиях --json
+	lineBuffered bool         // Сбрасывать вывод после каждой строки (--line-buffered)
+}
+
+// search ищет в r строки, совпадающие с m, и выводит их в w вместе
+// с контекстом в текстовом виде или событиями --json. Возвращает число
+// выбранных строк.
+func search(r io.Reader, w io.Writer, m grep.Matcher, opts searchOptions) (int, error) {
+	out := bufio.NewWriter(w)
+	var sink grep.Sink
+	if opts.json != nil {
+		sink = newJSONPrinter(out, opts.path, opts.json)
+	} else {
+		colors := opts.colors
+		if colors == nil {
+			colors = &colorScheme{}
+		}
+		sink = &printer{
+			out: out, colors: colors, label: opts.label,
+			lineNum: opts.lineNum, byteOffset: opts.byteOffset, onlyMatching: opts.onlyMatching,
+		}
+	}
+
+	if opts.lineBuffered {
+		sink = flushingSink{Sink: sink, out: out}
+	}
+
+	searcher := grep.NewSearcher(m, grep.SearchOptions{
+		Before: opts.before, After: opts.after, Invert: opts.invert,
+		MaxCount: opts.maxCount, Count: opts.count,
+		// Границы совпадений нужны только для подсветки, -o и --json
+		Submatches: opts.onlyMatching || opts.colors != nil || opts.json != nil,
+	})
+	stats, err := searcher.Search(r, sink)
+	if err != nil {
+		out.Flush()
+		return stats.Selected, err
+	}
+	return stats.Selected, out.Flush()
+}
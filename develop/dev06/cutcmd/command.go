@@ -0,0 +1,135 @@
+package cutcmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+
+	"golang.org/x/text/encoding"
+
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// Коды завершения, общие для утилит texttool
+const (
+	exitOK    = textcli.ExitOK    // Успешное завершение
+	exitError = textcli.ExitError // Ошибка в аргументах или при чтении файла
+)
+
+// maxRecordSize — максимальная длина строки, которую может прочитать сканер
+const maxRecordSize = 1 << 30
+
+// Run выполняет cut с аргументами командной строки и возвращает код завершения
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cut", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	// Флаги
+	fieldsFlag := fs.String("f", "", "Выбрать поля: список вида '1-3,5,7-'")
+	bytesFlag := fs.String("b", "", "Выбрать байты: список вида '1-3,5,7-'")
+	charsFlag := fs.String("c", "", "Выбрать символы: список вида '1-3,5,7-'")
+	complementFlag := fs.Bool("complement", false, "Выводить все, кроме выбранных полей, байтов или символов")
+	delimiterFlag := fs.String("d", "\t", "Разделитель (по умолчанию TAB)")
+	separatedFlag := fs.Bool("s", false, "Выводить только строки с разделителями")
+	outputDelimiterFlag := fs.String("output-delimiter", "", "Разделитель в выводе (по умолчанию совпадает с -d)")
+	zeroFlag := fs.Bool("z", false, "Строки оканчиваются нулевым байтом, а не переводом строки")
+	regexFlag := fs.Bool("regex", false, "Разделитель -d — регулярное выражение")
+	encodingName := fs.String("encoding", "", "Кодировка входных данных (windows-1251, koi8-r, utf-16le...); вывод всегда в UTF-8")
+	csvFlag := fs.Bool("csv", false, "Разбирать вход как CSV (RFC 4180); разделитель -d, по умолчанию запятая; -f может ссылаться на колонки по имени из заголовка")
+	if err := fs.Parse(textcli.ExpandShortFlags(fs, args)); err != nil {
+		return exitError
+	}
+
+	// fail выводит ошибку в stderr и возвращает код ошибки
+	fail := func(a ...interface{}) int {
+		return textcli.Fail(stderr, "cut", a...)
+	}
+
+	opts := cutter{
+		complement: *complementFlag, delimiter: *delimiterFlag, onlyDelimited: *separatedFlag, csv: *csvFlag,
+	}
+	delimiterSet := false
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "output-delimiter":
+			opts.outputDelimiter, opts.hasOutputDelimiter = *outputDelimiterFlag, true
+		case "d":
+			delimiterSet = true
+		}
+	})
+	if *csvFlag && !delimiterSet {
+		opts.delimiter = ","
+	}
+	if opts.delimiter == "" {
+		return fail("пустой разделитель -d")
+	}
+	if *regexFlag {
+		re, err := regexp.Compile(opts.delimiter)
+		if err != nil {
+			return fail("неверное регулярное выражение -d:", err)
+		}
+		opts.delimiterRe = re
+	}
+	if *csvFlag && *zeroFlag {
+		return fail("-z нельзя сочетать с --csv")
+	}
+	c, err := newCutter(*fieldsFlag, *bytesFlag, *charsFlag, opts)
+	if err != nil {
+		return fail(err)
+	}
+	enc, err := textcli.LookupEncoding(*encodingName)
+	if err != nil {
+		return fail(err)
+	}
+
+	terminator := byte('\n')
+	if *zeroFlag {
+		terminator = 0
+	}
+
+	// Без аргументов читаем стандартный ввод, "-" тоже означает стандартный ввод
+	files := textcli.Args(fs.Args())
+
+	// Ошибка в одном файле не мешает обработать остальные
+	out := bufio.NewWriter(stdout)
+	code := exitOK
+	for _, name := range files {
+		if err := cutInput(c, name, stdin, enc, out, terminator); err != nil {
+			code = fail(err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return fail("ошибка записи:", err)
+	}
+	return code
+}
+
+// cutInput обрабатывает файл name ("-" — стандартный ввод) в кодировке enc
+// и пишет результат в out. terminator — символ конца строки во входе и в выводе.
+func cutInput(c *cutter, name string, stdin io.Reader, enc encoding.Encoding, out *bufio.Writer, terminator byte) error {
+	in, err := textcli.Open(name, stdin, enc)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if c.csv {
+		return c.cutCSV(name, in, out)
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+	scanner.Split(textcli.ScanRecords(terminator))
+	for scanner.Scan() {
+		if output, ok := c.cut(scanner.Text()); ok {
+			out.WriteString(output)
+			out.WriteByte(terminator)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения %s: %v", name, err)
+	}
+	return nil
+}
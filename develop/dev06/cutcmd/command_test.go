@@ -0,0 +1,198 @@
+package cutcmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		spec string
+		want fieldList
+	}{
+		{"1", fieldList{{1, 1}}},
+		{"1-3,5,7-", fieldList{{1, 3}, {5, 5}, {7, 0}}},
+		{"-3", fieldList{{1, 3}}},
+		{"5,1,3", fieldList{{1, 1}, {3, 3}, {5, 5}}},
+		{"3,1-2", fieldList{{1, 3}}},
+		{"2-4,3-6,6", fieldList{{2, 6}}},
+		{"1,1,1", fieldList{{1, 1}}},
+		{"4-,2-3,9", fieldList{{2, 0}}},
+		{"2-,5-7", fieldList{{2, 0}}},
+	}
+	for _, test := range tests {
+		got, err := parseList(test.spec)
+		if err != nil {
+			t.Errorf("parseList(%q): %v", test.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseList(%q) = %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestParseListErrors(t *testing.T) {
+	for _, spec := range []string{"", "0", "-1-2", "1,", ",2", "a", "3-1", "-", "1-0", "+2", "1,0-3"} {
+		if _, err := parseList(spec); err == nil {
+			t.Errorf("parseList(%q): expected error", spec)
+		}
+	}
+}
+
+func TestCut(t *testing.T) {
+	tests := []struct {
+		name                string
+		fields, bytes, char string
+		opts                cutter
+		line                string
+		want                string
+		wantOK              bool
+	}{
+		{"single field", "2", "", "", cutter{delimiter: ","}, "a,b,c", "b", true},
+		{"range and open end", "1-2,4-", "", "", cutter{delimiter: ","}, "a,b,c,d,e", "a,b,d,e", true},
+		{"input order and dedup", "3,1,3,1-1", "", "", cutter{delimiter: ","}, "a,b,c", "a,c", true},
+		{"field past end", "2,5", "", "", cutter{delimiter: ","}, "a,b", "b", true},
+		{"empty fields kept", "2-3", "", "", cutter{delimiter: ","}, "a,,c", ",c", true},
+		{"no delimiter printed", "2", "", "", cutter{delimiter: ","}, "abc", "abc", true},
+		{"no delimiter skipped with -s", "2", "", "", cutter{delimiter: ",", onlyDelimited: true}, "abc", "", false},
+		{"complement fields", "2", "", "", cutter{delimiter: ",", complement: true}, "a,b,c", "a,c", true},
+		{"complement open range", "2-", "", "", cutter{delimiter: ":", complement: true}, "a:b:c", "a", true},
+		{"bytes", "", "1-3,5", "", cutter{}, "abcdef", "abce", true},
+		{"bytes split runes", "", "1", "", cutter{}, "яблоко", "\xd1", true},
+		{"bytes complement", "", "2-", "", cutter{complement: true}, "hello", "h", true},
+		{"chars are runes", "", "", "1,3-4", cutter{}, "яблоко", "яло", true},
+		{"chars open end", "", "", "5-", cutter{}, "привет", "ет", true},
+		{"chars complement", "", "", "1", cutter{complement: true}, "ёжик", "жик", true},
+		{"chars invalid byte", "", "", "2", cutter{}, "a\xffb", "\xff", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := newCutter(test.fields, test.bytes, test.char, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := c.cut(test.line)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("cut(%q) = %q, %v, want %q, %v", test.line, got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewCutterErrors(t *testing.T) {
+	tests := []struct {
+		name                string
+		fields, bytes, char string
+		opts                cutter
+	}{
+		{"no list", "", "", "", cutter{}},
+		{"two lists", "1", "2", "", cutter{}},
+		{"only delimited with bytes", "", "1", "", cutter{onlyDelimited: true}},
+		{"zero field", "0", "", "", cutter{}},
+	}
+	for _, test := range tests {
+		if _, err := newCutter(test.fields, test.bytes, test.char, test.opts); err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+	}
+}
+
+func TestOutputDelimiter(t *testing.T) {
+	tests := []struct {
+		name                string
+		fields, bytes, char string
+		line                string
+		want                string
+	}{
+		{"fields", "1,3", "", "", "a,b,c", "a|c"},
+		{"bytes between ranges", "", "1-2,4,5", "", "abcdef", "ab|de"},
+		{"chars between ranges", "", "", "1,3-", "ёжик", "ё|ик"},
+	}
+	for _, test := range tests {
+		c, err := newCutter(test.fields, test.bytes, test.char, cutter{
+			delimiter: ",", outputDelimiter: "|", hasOutputDelimiter: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := c.cut(test.line); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.txt")
+	second := filepath.Join(dir, "second.txt")
+	if err := os.WriteFile(first, []byte("a:b:c\nd:e:f\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte("g:h\nnodelim"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		want       string
+		wantCode   int
+		wantStderr string
+	}{
+		{"stdin by default", []string{"-d", ":", "-f", "2"}, "x:y\n", "y\n", exitOK, ""},
+		{"stdin as dash", []string{"-d", ":", "-f", "1", first, "-"}, "x:y\n", "a\nd\nx\n", exitOK, ""},
+		{"several files in order", []string{"-d", ":", "-f", "2", first, second}, "", "b\ne\nh\nnodelim\n", exitOK, ""},
+		{"output delimiter", []string{"-d", ":", "-f", "1,3", "--output-delimiter", " - ", first}, "", "a - c\nd - f\n", exitOK, ""},
+		{"empty output delimiter", []string{"-d", ":", "-f", "1-", "--output-delimiter=", first}, "", "abc\ndef\n", exitOK, ""},
+		{"nul records", []string{"-z", "-d", ":", "-f", "2"}, "a:b\nc\x00d:e", "b\nc\x00e\x00", exitOK, ""},
+		{"missing file continues", []string{"-c", "1", missing, first}, "", "a\nd\n", exitError, "cut: " + missing + ": no such file or directory\n"},
+		{"bad list", []string{"-f", "0"}, "", "", exitError, "cut: позиции нумеруются с 1\n"},
+		{"no list", []string{}, "a\n", "", exitError, "cut: нужно указать ровно один список: байтов (-b), символов (-c) или полей (-f)\n"},
+		{"empty delimiter", []string{"-d", "", "-f", "1"}, "", "", exitError, "cut: пустой разделитель -d\n"},
+		{"multi-character delimiter", []string{"-d", "::", "-f", "2"}, "a::b:c::d\nnodelim\n", "b:c\nnodelim\n", exitOK, ""},
+		{"regex delimiter", []string{"--regex", "-d", "[ \t]+", "-f", "1,3"}, "a  b\tc\nx y z\n", "a  c\nx z\n", exitOK, ""},
+		{"regex delimiter with output delimiter", []string{"--regex", "-d", " +", "-f", "2-", "--output-delimiter", ","}, "a  b c\n", "b,c\n", exitOK, ""},
+		{"regex delimiter only delimited", []string{"--regex", "-d", ",|;", "-s", "-f", "2"}, "a;b\nnodelim\n", "b\n", exitOK, ""},
+		{"bad regex delimiter", []string{"--regex", "-d", "(", "-f", "1"}, "", "", exitError, "cut: неверное регулярное выражение -d: error parsing regexp: missing closing ): `(`\n"},
+		{"csv quoted delimiter", []string{"--csv", "-f", "2"}, "1,\"Doe, John\",x\n2,plain,y\n", "\"Doe, John\"\nplain\n", exitOK, ""},
+		{"csv quoted newline", []string{"--csv", "-f", "1,3"}, "\"a\nb\",skip,\"say \"\"hi\"\"\"\n", "\"a\nb\",\"say \"\"hi\"\"\"\n", exitOK, ""},
+		{"csv header names", []string{"--csv", "-f", "email,name"}, "id,name,email\n1,Ann,ann@example.com\n", "name,email\nAnn,ann@example.com\n", exitOK, ""},
+		{"csv name range", []string{"--csv", "-f", "name-"}, "id,name,email\n1,Ann,a@b\n", "name,email\nAnn,a@b\n", exitOK, ""},
+		{"csv complement by name", []string{"--csv", "--complement", "-f", "id"}, "id,name\n1,Ann\n", "name\nAnn\n", exitOK, ""},
+		{"csv custom delimiters", []string{"--csv", "-d", ";", "-f", "1,2", "--output-delimiter", ","}, "a,1;b\n", "\"a,1\",b\n", exitOK, ""},
+		{"csv only delimited", []string{"--csv", "-s", "-f", "1"}, "single\na,b\n", "a\n", exitOK, ""},
+		{"csv unknown column", []string{"--csv", "-f", "phone"}, "id,name\n1,Ann\n", "", exitError, "cut: в заголовке нет колонки \"phone\"\n"},
+		{"csv unknown range bound", []string{"--csv", "-f", "name-phone"}, "id,name\n1,Ann\n", "", exitError, "cut: в заголовке нет колонки \"name-phone\"\n"},
+		{"csv name with dash", []string{"--csv", "-f", "e-mail"}, "id,e-mail\n1,a@x\n", "e-mail\na@x\n", exitOK, ""},
+		{"csv range to name with dash", []string{"--csv", "-f", "id-e-mail"}, "id,e-mail,x\n1,a@x,y\n", "id,e-mail\n1,a@x\n", exitOK, ""},
+		{"csv malformed", []string{"--csv", "-f", "1"}, "x,y\na,b\"c\n", "x\n", exitError, "cut: ошибка чтения -: parse error on line 2, column 4: bare \" in non-quoted-field\n"},
+		{"csv multi-character delimiter", []string{"--csv", "-d", "::", "-f", "1"}, "", "", exitError, "cut: разделитель -d в режиме --csv должен быть одним символом, кроме кавычки и перевода строки\n"},
+		{"csv with bytes", []string{"--csv", "-b", "1"}, "", "", exitError, "cut: в режиме --csv можно выбирать только поля (-f)\n"},
+		{"encoding", []string{"--encoding", "windows-1251", "-d:", "-f2"}, "\xe0:\xe1\xe2\n", "бв\n", exitOK, ""},
+		{"unknown encoding", []string{"--encoding", "nope", "-f1"}, "", "", exitError, "cut: неизвестная кодировка \"nope\"\n"},
+		{"glued short flags", []string{"-d:", "-sf2"}, "a:b\nc\n", "b\n", exitOK, ""},
+		{"names without csv", []string{"-f", "name"}, "", "", exitError, "cut: имена колонок в списке полей можно указывать только с --csv\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := Run(test.args, strings.NewReader(test.stdin), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q", stdout.String(), test.want)
+			}
+			if code != test.wantCode {
+				t.Errorf("got exit code %d, want %d", code, test.wantCode)
+			}
+			if stderr.String() != test.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr.String(), test.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package cutcmd
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// hasNames сообщает, что в списке полей есть имена колонок, а не только номера
+func hasNames(spec string) bool {
+	for _, item := range strings.Split(spec, ",") {
+		if !isNumeric(item) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNumeric сообщает, что элемент списка полей — номер или диапазон номеров
+func isNumeric(item string) bool {
+	from, to, _ := strings.Cut(item, "-")
+	for _, part := range []string{from, to} {
+		if _, err := strconv.Atoi(part); part != "" && err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// resolveNames заменяет в списке полей имена колонок их номерами по заголовку.
+// Имя может быть отдельным элементом или границей диапазона ("name-email");
+// элемент сначала ищется в заголовке целиком, поэтому имя может содержать "-".
+func resolveNames(spec string, header []string) (string, error) {
+	items := strings.Split(spec, ",")
+	for i, item := range items {
+		if isNumeric(item) {
+			continue
+		}
+		if pos := columnIndex(header, item); pos > 0 {
+			items[i] = strconv.Itoa(pos)
+			continue
+		}
+
+		from, to, isRange := strings.Cut(item, "-")
+		parts := []string{from, to}
+		for j, part := range parts {
+			if _, err := strconv.Atoi(part); part == "" || err == nil {
+				continue
+			}
+			pos := columnIndex(header, part)
+			if pos == 0 {
+				return "", fmt.Errorf("в заголовке нет колонки %q", item)
+			}
+			parts[j] = strconv.Itoa(pos)
+		}
+		items[i] = parts[0]
+		if isRange {
+			items[i] += "-" + parts[1]
+		}
+	}
+	return strings.Join(items, ","), nil
+}
+
+// columnIndex возвращает номер колонки name в заголовке (с 1), 0 — колонки нет
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if column == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// singleRune возвращает единственный символ строки s; what описывает s в ошибке
+func singleRune(s, what string) (rune, error) {
+	runes := []rune(s)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("%s в режиме --csv должен быть одним символом, кроме кавычки и перевода строки", what)
+	}
+	return runes[0], nil
+}
+
+// cutCSV обрабатывает вход в режиме --csv: записи разбираются по RFC 4180,
+// поэтому разделитель и переводы строк внутри кавычек не разбивают поле, а
+// выбранные поля записываются обратно с кавычками там, где они нужны.
+// Если в списке полей есть имена, они ищутся в первой записи — заголовке.
+// name — имя входа в сообщениях об ошибках чтения.
+func (c *cutter) cutCSV(name string, in io.Reader, out io.Writer) error {
+	r := csv.NewReader(in)
+	r.Comma = c.comma
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	w := csv.NewWriter(out)
+	w.Comma = c.outputComma
+
+	list := c.list
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Записи до ошибки уже выбраны и должны попасть в вывод
+			w.Flush()
+			return fmt.Errorf("ошибка чтения %s: %v", name, err)
+		}
+		if first && list == nil {
+			spec, err := resolveNames(c.fieldSpec, record)
+			if err != nil {
+				return err
+			}
+			if list, err = parseList(spec); err != nil {
+				return err
+			}
+		}
+
+		// Запись из одного поля — аналог строки без разделителя
+		if len(record) == 1 {
+			if !c.onlyDelimited {
+				w.Write(record)
+			}
+			continue
+		}
+		output := make([]string, 0, len(record))
+		for i, field := range record {
+			if list.contains(i+1) != c.complement {
+				output = append(output, field)
+			}
+		}
+		w.Write(output)
+	}
+	w.Flush()
+	return w.Error()
+}
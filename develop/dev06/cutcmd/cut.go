@@ -0,0 +1,199 @@
+package cutcmd
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// cutMode — единица, из которой выбираются позиции
+type cutMode int
+
+const (
+	modeFields cutMode = iota // Поля, разделенные -d (-f)
+	modeBytes                 // Байты (-b)
+	modeChars                 // Символы UTF-8 (-c)
+)
+
+// cutter вырезает из строк выбранные поля, байты или символы
+type cutter struct {
+	mode          cutMode
+	list          fieldList
+	complement    bool   // Выводить позиции, не вошедшие в список (--complement)
+	delimiter     string // Разделитель полей (-d)
+	onlyDelimited bool   // Пропускать строки без разделителя (-s)
+
+	outputDelimiter    string         // Разделитель в выводе (--output-delimiter)
+	hasOutputDelimiter bool           // --output-delimiter задан явно
+	delimiterRe        *regexp.Regexp // Разделитель — регулярное выражение (--regex)
+
+	csv         bool   // Разбирать вход как CSV (--csv)
+	comma       rune   // Разделитель CSV во входе
+	outputComma rune   // Разделитель CSV в выводе
+	fieldSpec   string // Список полей с именами колонок, разрешается по заголовку
+}
+
+// newCutter проверяет сочетание флагов и создает cutter. Ровно один из списков
+// fields, bytes, chars должен быть непустым.
+func newCutter(fields, bytes, chars string, c cutter) (*cutter, error) {
+	spec := ""
+	modes := 0
+	for _, m := range []struct {
+		spec string
+		mode cutMode
+	}{{fields, modeFields}, {bytes, modeBytes}, {chars, modeChars}} {
+		if m.spec != "" {
+			spec, c.mode = m.spec, m.mode
+			modes++
+		}
+	}
+	if modes != 1 {
+		return nil, errors.New("нужно указать ровно один список: байтов (-b), символов (-c) или полей (-f)")
+	}
+	if c.mode != modeFields && c.onlyDelimited {
+		return nil, errors.New("флаг -s имеет смысл только при выборе полей")
+	}
+	if c.csv {
+		if err := c.initCSV(); err != nil {
+			return nil, err
+		}
+	}
+	if c.mode == modeFields && hasNames(spec) {
+		if !c.csv {
+			return nil, errors.New("имена колонок в списке полей можно указывать только с --csv")
+		}
+		// Номера колонок станут известны после чтения заголовка
+		c.fieldSpec = spec
+		return &c, nil
+	}
+
+	list, err := parseList(spec)
+	if err != nil {
+		return nil, err
+	}
+	c.list = list
+	return &c, nil
+}
+
+// initCSV проверяет параметры режима --csv
+func (c *cutter) initCSV() error {
+	if c.mode != modeFields {
+		return errors.New("в режиме --csv можно выбирать только поля (-f)")
+	}
+	if c.delimiterRe != nil {
+		return errors.New("--regex нельзя сочетать с --csv")
+	}
+	var err error
+	if c.comma, err = singleRune(c.delimiter, "разделитель -d"); err != nil {
+		return err
+	}
+	c.outputComma = c.comma
+	if c.hasOutputDelimiter {
+		c.outputComma, err = singleRune(c.outputDelimiter, "--output-delimiter")
+	}
+	return err
+}
+
+// selected сообщает, что позицию pos нужно вывести
+func (c *cutter) selected(pos int) bool {
+	return c.list.contains(pos) != c.complement
+}
+
+// cut возвращает выбранную часть строки; ok == false — строку выводить не нужно
+func (c *cutter) cut(line string) (result string, ok bool) {
+	switch c.mode {
+	case modeBytes:
+		return c.cutBytes(line), true
+	case modeChars:
+		return c.cutChars(line), true
+	}
+	return c.cutFields(line)
+}
+
+// cutFields выбирает поля. Строка без разделителя выводится целиком, как в
+// GNU cut, или пропускается с -s.
+func (c *cutter) cutFields(line string) (string, bool) {
+	fields, separator := c.splitFields(line)
+	if len(fields) == 1 {
+		if c.onlyDelimited {
+			return "", false
+		}
+		return line, true
+	}
+
+	var output []string
+	for i, field := range fields {
+		if c.selected(i + 1) {
+			output = append(output, field)
+		}
+	}
+	if c.hasOutputDelimiter {
+		separator = c.outputDelimiter
+	}
+	return strings.Join(output, separator), true
+}
+
+// splitFields разбивает строку на поля и возвращает разделитель для вывода
+// по умолчанию: -d или, для --regex, первый найденный в строке разделитель.
+// Пустые совпадения регулярного выражения разделителями не считаются.
+func (c *cutter) splitFields(line string) ([]string, string) {
+	if c.delimiterRe == nil {
+		return strings.Split(line, c.delimiter), c.delimiter
+	}
+
+	var fields []string
+	separator := ""
+	pos := 0
+	for _, loc := range c.delimiterRe.FindAllStringIndex(line, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		if separator == "" {
+			separator = line[loc[0]:loc[1]]
+		}
+		fields = append(fields, line[pos:loc[0]])
+		pos = loc[1]
+	}
+	return append(fields, line[pos:]), separator
+}
+
+// cutBytes выбирает байты; многобайтовый символ может оказаться разрезан
+func (c *cutter) cutBytes(line string) string {
+	var b strings.Builder
+	last := 0 // Последняя выведенная позиция
+	for i := 0; i < len(line); i++ {
+		if c.selected(i + 1) {
+			c.writeRangeSeparator(&b, last, i+1)
+			b.WriteByte(line[i])
+			last = i + 1
+		}
+	}
+	return b.String()
+}
+
+// cutChars выбирает символы UTF-8; некорректный байт считается одним символом
+func (c *cutter) cutChars(line string) string {
+	var b strings.Builder
+	pos, last := 0, 0
+	for i := 0; i < len(line); {
+		_, size := utf8.DecodeRuneInString(line[i:])
+		pos++
+		if c.selected(pos) {
+			c.writeRangeSeparator(&b, last, pos)
+			b.WriteString(line[i : i+size])
+			last = pos
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// writeRangeSeparator выводит --output-delimiter между несмежными выбранными
+// диапазонами байтов или символов, как GNU cut; last — предыдущая выведенная
+// позиция (0 — еще ничего не выведено), pos — текущая
+func (c *cutter) writeRangeSeparator(b *strings.Builder, last, pos int) {
+	if c.hasOutputDelimiter && last > 0 && pos > last+1 {
+		b.WriteString(c.outputDelimiter)
+	}
+}
@@ -0,0 +1,106 @@
+package cutcmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// span — диапазон позиций включительно, позиции нумеруются с 1;
+// end == 0 означает диапазон до конца строки
+type span struct {
+	start, end int
+}
+
+// fieldList — список позиций для -f, -b и -c в синтаксисе POSIX: "1-3,5,7-".
+// Диапазоны упорядочены и не пересекаются, поэтому позиции выводятся
+// в порядке входа и без повторов, в каком бы порядке их ни перечислили.
+type fieldList []span
+
+// parseList разбирает список позиций. Элементы разделяются запятыми:
+// N — одна позиция, N-M — диапазон, N- — от N до конца, -M — от 1 до M.
+func parseList(spec string) (fieldList, error) {
+	if spec == "" {
+		return nil, errors.New("пустой список позиций")
+	}
+
+	var list fieldList
+	for _, item := range strings.Split(spec, ",") {
+		s, err := parseSpan(item)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].start < list[j].start })
+	// Сливаем пересекающиеся и соседние диапазоны
+	merged := list[:1]
+	for _, s := range list[1:] {
+		last := &merged[len(merged)-1]
+		if last.end != 0 && s.start > last.end+1 {
+			merged = append(merged, s)
+			continue
+		}
+		if last.end != 0 && (s.end == 0 || s.end > last.end) {
+			last.end = s.end
+		}
+	}
+	return merged, nil
+}
+
+// parseSpan разбирает один элемент списка
+func parseSpan(item string) (span, error) {
+	from, to, isRange := strings.Cut(item, "-")
+	if !isRange {
+		n, err := parsePosition(item)
+		return span{n, n}, err
+	}
+	if from == "" && to == "" {
+		return span{}, fmt.Errorf("неверный диапазон %q: не указана ни одна граница", item)
+	}
+
+	s := span{start: 1}
+	var err error
+	if from != "" {
+		if s.start, err = parsePosition(from); err != nil {
+			return span{}, err
+		}
+	}
+	if to != "" {
+		if s.end, err = parsePosition(to); err != nil {
+			return span{}, err
+		}
+		if s.end < s.start {
+			return span{}, fmt.Errorf("неверный диапазон %q: границы убывают", item)
+		}
+	}
+	return s, nil
+}
+
+// parsePosition разбирает номер позиции: целое число не меньше 1
+func parsePosition(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || strings.HasPrefix(s, "+") {
+		return 0, fmt.Errorf("неверное значение позиции %q", s)
+	}
+	if n < 1 {
+		return 0, errors.New("позиции нумеруются с 1")
+	}
+	return n, nil
+}
+
+// contains сообщает, что позиция pos входит в список
+func (l fieldList) contains(pos int) bool {
+	for _, s := range l {
+		if pos < s.start {
+			return false
+		}
+		if s.end == 0 || pos <= s.end {
+			return true
+		}
+	}
+	return false
+}
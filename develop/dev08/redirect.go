@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"wb-tech-l2/develop/internal/shellparse"
+)
+
+// streams — стандартные потоки команды
+type streams struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// applyRedirects применяет перенаправления слева направо к потокам s, поэтому
+// "> file 2>&1" отправляет в файл оба потока, а "2>&1 > file" — только stdout.
+// Возвращает новые потоки и открытые файлы, которые нужно закрыть после
+// выполнения команды.
+func applyRedirects(redirects []shellparse.Redirect, s streams) (streams, []io.Closer, error) {
+	var files []io.Closer
+	for _, r := range redirects {
+		if err := applyRedirect(r, &s, &files); err != nil {
+			closeAll(files)
+			return streams{}, nil, err
+		}
+	}
+	return s, files, nil
+}
+
+// applyRedirect применяет одно перенаправление к потокам s;
+// открытый файл добавляется в files
+func applyRedirect(r shellparse.Redirect, s *streams, files *[]io.Closer) error {
+	switch r.Op {
+	case "<", "<<":
+		if r.Fd != 0 {
+			return fmt.Errorf("%d%s: перенаправление ввода возможно только для дескриптора 0", r.Fd, r.Op)
+		}
+		if r.Op == "<<" {
+			s.stdin = strings.NewReader(r.Body)
+			return nil
+		}
+		file, err := os.Open(r.Target)
+		if err != nil {
+			return err
+		}
+		*files = append(*files, file)
+		s.stdin = file
+	case ">", ">>", "&>", "&>>":
+		both := r.Op[0] == '&'
+		if !both && r.Fd != 1 && r.Fd != 2 {
+			return fmt.Errorf("%d%s: перенаправление вывода возможно только для дескрипторов 1 и 2", r.Fd, r.Op)
+		}
+		flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if strings.HasSuffix(r.Op, ">>") {
+			flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(r.Target, flag, 0o644)
+		if err != nil {
+			return err
+		}
+		*files = append(*files, file)
+		switch {
+		case both:
+			s.stdout, s.stderr = file, file
+		case r.Fd == 1:
+			s.stdout = file
+		default:
+			s.stderr = file
+		}
+	case ">&":
+		var target io.Writer
+		switch r.Target {
+		case "1":
+			target = s.stdout
+		case "2":
+			target = s.stderr
+		default:
+			return fmt.Errorf("%s: неверный дескриптор", r.Target)
+		}
+		switch r.Fd {
+		case 1:
+			s.stdout = target
+		case 2:
+			s.stderr = target
+		default:
+			return fmt.Errorf("%d>&: копирование возможно только для дескрипторов 1 и 2", r.Fd)
+		}
+	}
+	return nil
+}
+
+// closeAll закрывает файлы и концы каналов
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		c.Close()
+	}
+}
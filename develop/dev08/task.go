@@ -2,11 +2,16 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
+
+	"wb-tech-l2/develop/internal/shellparse"
 )
 
 // Главная функция программы
@@ -19,176 +24,281 @@ func main() {
 
 		// Считываем ввод пользователя
 		input, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ошибка ввода:", err)
-			continue
+		if errors.Is(err, io.EOF) && input == "" {
+			fmt.Println()
+			break
 		}
-
-		// Убираем лишние пробелы и символы новой строки
-		input = strings.TrimSpace(input)
-		if input == "" {
+		if err != nil && !errors.Is(err, io.EOF) {
+			fmt.Fprintln(os.Stderr, "Ошибка ввода:", err)
 			continue
 		}
 
 		// Проверяем команду на выход
-		if input == "\\quit" {
+		if strings.TrimSpace(input) == "\\quit" {
 			fmt.Println("Выход из шелла.")
 			break
 		}
 
-		// Разделение команды на части (обработка пайпов)
-		commands := strings.Split(input, "|")
-		if len(commands) > 1 {
-			handlePipes(commands)
+		// Разбираем команду; незакрытые кавычки, '|' или '&&' в конце строки
+		// означают, что команда продолжается на следующей строке
+		list, err := shellparse.Parse(input)
+		for errors.Is(err, shellparse.ErrIncomplete) {
+			fmt.Print("> ")
+			line, readErr := reader.ReadString('\n')
+			if line == "" && readErr != nil {
+				break
+			}
+			input += line
+			list, err = shellparse.Parse(input)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Ошибка:", err)
 			continue
 		}
 
-		// Обработка одиночной команды
-		handleCommand(input)
+		runList(list)
+	}
+}
+
+// runList выполняет список конвейеров с учетом операторов '&&' и '||'
+// и возвращает код завершения последнего выполненного конвейера
+func runList(list *shellparse.List) int {
+	status := 0
+	for _, item := range list.Items {
+		if item.Op == shellparse.OpAnd && status != 0 || item.Op == shellparse.OpOr && status == 0 {
+			continue
+		}
+		status = runPipeline(item.Pipeline)
 	}
+	return status
 }
 
-// Функция обработки одиночной команды
-func handleCommand(input string) {
-	args := strings.Fields(input)
-	if len(args) == 0 {
-		return
-	}
-
-	switch args[0] {
-	case "cd":
-		changeDirectory(args)
-	case "pwd":
-		printWorkingDirectory()
-	case "echo":
-		echo(args)
-	case "kill":
-		killProcess(args)
-	case "ps":
-		listProcesses()
-	default:
-		executeCommand(args)
+// runPipeline выполняет конвейер и возвращает код завершения его последней команды.
+// Команды соединяются каналами ОС, перенаправления каждой команды применяются
+// поверх каналов. Встроенные команды выполняются в горутинах, поэтому
+// их тоже можно ставить в любое место конвейера; в конвейере из нескольких
+// команд они, как в подоболочке POSIX shell, не меняют состояние шелла.
+func runPipeline(pipeline *shellparse.Pipeline) int {
+	n := len(pipeline.Commands)
+
+	// Каналы между соседними командами
+	readers := make([]*os.File, n)
+	writers := make([]*os.File, n)
+	for i := 0; i < n-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Ошибка создания пайпа:", err)
+			for j := 0; j < i; j++ {
+				readers[j+1].Close()
+				writers[j].Close()
+			}
+			return 1
+		}
+		readers[i+1], writers[i] = r, w
+	}
+
+	// Запуск всех команд
+	waits := make([]func() int, n)
+	for i, command := range pipeline.Commands {
+		base := streams{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+		var pipes []io.Closer
+		if readers[i] != nil {
+			base.stdin = readers[i]
+			pipes = append(pipes, readers[i])
+		}
+		if writers[i] != nil {
+			base.stdout = writers[i]
+			pipes = append(pipes, writers[i])
+		}
+		waits[i] = startCommand(command, base, pipes, n > 1)
 	}
+
+	// Ожидание завершения команд
+	status := 0
+	for _, wait := range waits {
+		status = wait()
+	}
+	return status
+}
+
+// builtins — встроенные команды шелла
+var builtins = map[string]func(args []string, s streams) int{
+	"cd":   changeDirectory,
+	"pwd":  printWorkingDirectory,
+	"echo": echo,
+	"kill": killProcess,
+	"ps":   listProcesses,
+}
+
+// subshellBuiltins заменяют встроенные команды, меняющие состояние шелла,
+// внутри конвейера из нескольких команд. POSIX shell выполняет такие команды
+// в подоболочке, поэтому "cd dir | cat" не меняет текущий каталог шелла.
+var subshellBuiltins = map[string]func(args []string, s streams) int{
+	"cd": checkDirectory,
+}
+
+// startCommand применяет перенаправления и запускает команду с потоками base.
+// pipes — концы каналов команды: шелл закрывает их, как только они больше
+// не нужны, чтобы соседние команды получили конец ввода. subshell — команда
+// выполняется в конвейере из нескольких команд. Возвращает функцию,
+// которая дожидается завершения команды и возвращает ее код.
+func startCommand(command *shellparse.Command, base streams, pipes []io.Closer, subshell bool) func() int {
+	s, files, err := applyRedirects(command.Redirects, base)
+	if err != nil {
+		fmt.Fprintln(base.stderr, "Ошибка перенаправления:", err)
+		closeAll(pipes)
+		return func() int { return 1 }
+	}
+
+	// Команда из одних перенаправлений только создает файлы
+	if len(command.Args) == 0 {
+		closeAll(files)
+		closeAll(pipes)
+		return func() int { return 0 }
+	}
+
+	builtin, ok := builtins[command.Args[0]]
+	if replacement, found := subshellBuiltins[command.Args[0]]; found && subshell {
+		builtin = replacement
+	}
+	if ok {
+		done := make(chan int, 1)
+		go func() {
+			status := builtin(command.Args, s)
+			closeAll(files)
+			closeAll(pipes)
+			done <- status
+		}()
+		return func() int { return <-done }
+	}
+
+	cmd := exec.Command(command.Args[0], command.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = s.stdin, s.stdout, s.stderr
+	err = cmd.Start()
+	// Запущенный процесс получил свои копии дескрипторов
+	closeAll(pipes)
+	if err != nil {
+		status := commandStatus(s.stderr, "Ошибка запуска команды:", err)
+		closeAll(files)
+		return func() int { return status }
+	}
+	return func() int {
+		status := commandStatus(s.stderr, "Ошибка выполнения команды:", cmd.Wait())
+		closeAll(files)
+		return status
+	}
+}
+
+// exitStatus возвращает код завершения внешней команды по ошибке ее выполнения.
+// Команду, которую не удалось запустить, shell завершает с кодом 127.
+func exitStatus(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode()
+	}
+	return 127
+}
+
+// commandStatus возвращает код завершения внешней команды. Ненулевой код
+// ошибкой шелла не считается, о прочих ошибках в stderr выводится сообщение message.
+func commandStatus(stderr io.Writer, message string, err error) int {
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		fmt.Fprintln(stderr, message, err)
+	}
+	return exitStatus(err)
 }
 
 // Команда `cd`
-func changeDirectory(args []string) {
+func changeDirectory(args []string, s streams) int {
 	if len(args) < 2 {
-		fmt.Fprintln(os.Stderr, "Ошибка: отсутствует аргумент для cd")
-		return
+		fmt.Fprintln(s.stderr, "Ошибка: отсутствует аргумент для cd")
+		return 1
 	}
 	err := os.Chdir(args[1])
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка смены директории:", err)
+		fmt.Fprintln(s.stderr, "Ошибка смены директории:", err)
+		return 1
+	}
+	return 0
+}
+
+// checkDirectory — команда `cd` в конвейере: проверяет аргумент,
+// но текущий каталог шелла не меняет
+func checkDirectory(args []string, s streams) int {
+	if len(args) < 2 {
+		fmt.Fprintln(s.stderr, "Ошибка: отсутствует аргумент для cd")
+		return 1
+	}
+	// Ошибка описывается так же, как у os.Chdir
+	info, err := os.Stat(args[1])
+	var pathErr *os.PathError
+	switch {
+	case errors.As(err, &pathErr):
+		pathErr.Op = "chdir"
+	case err == nil && !info.IsDir():
+		err = &os.PathError{Op: "chdir", Path: args[1], Err: syscall.ENOTDIR}
+	}
+	if err != nil {
+		fmt.Fprintln(s.stderr, "Ошибка смены директории:", err)
+		return 1
 	}
+	return 0
 }
 
 // Команда `pwd`
-func printWorkingDirectory() {
+func printWorkingDirectory(_ []string, s streams) int {
 	cwd, err := os.Getwd()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка получения текущей директории:", err)
-		return
+		fmt.Fprintln(s.stderr, "Ошибка получения текущей директории:", err)
+		return 1
 	}
-	fmt.Println(cwd)
+	fmt.Fprintln(s.stdout, cwd)
+	return 0
 }
 
 // Команда `echo`
-func echo(args []string) {
-	fmt.Println(strings.Join(args[1:], " "))
+func echo(args []string, s streams) int {
+	fmt.Fprintln(s.stdout, strings.Join(args[1:], " "))
+	return 0
 }
 
 // Команда `kill`
-func killProcess(args []string) {
+func killProcess(args []string, s streams) int {
 	if len(args) < 2 {
-		fmt.Fprintln(os.Stderr, "Ошибка: необходимо указать PID")
-		return
+		fmt.Fprintln(s.stderr, "Ошибка: необходимо указать PID")
+		return 1
 	}
 	pid, err := strconv.Atoi(args[1])
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка: неверный PID")
-		return
+		fmt.Fprintln(s.stderr, "Ошибка: неверный PID")
+		return 1
 	}
 
 	// Находим процесс по PID
 	process, err := os.FindProcess(pid)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка: процесс не найден:", err)
-		return
+		fmt.Fprintln(s.stderr, "Ошибка: процесс не найден:", err)
+		return 1
 	}
 
 	// Завершаем процесс
 	err = process.Kill()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка завершения процесса:", err)
-	} else {
-		fmt.Printf("Процесс %d успешно завершён.\n", pid)
+		fmt.Fprintln(s.stderr, "Ошибка завершения процесса:", err)
+		return 1
 	}
+	fmt.Fprintf(s.stdout, "Процесс %d успешно завершён.\n", pid)
+	return 0
 }
 
 // Команда `ps`
-func listProcesses() {
+func listProcesses(_ []string, s streams) int {
 	cmd := exec.Command("ps", "-e")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка выполнения команды ps:", err)
-	}
-}
-
-// Выполнение произвольной команды
-func executeCommand(args []string) {
-	cmd := exec.Command(args[0], args[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Ошибка выполнения команды:", err)
-	}
-}
-
-// Обработка пайпов
-func handlePipes(commands []string) {
-	var processes []*exec.Cmd
-
-	// Создание списка процессов
-	for _, command := range commands {
-		args := strings.Fields(strings.TrimSpace(command))
-		if len(args) == 0 {
-			fmt.Fprintln(os.Stderr, "Ошибка: пустая команда в пайпе")
-			return
-		}
-		processes = append(processes, exec.Command(args[0], args[1:]...))
-	}
-
-	// Настройка пайпов
-	for i := 0; i < len(processes)-1; i++ {
-		pipe, err := processes[i].StdoutPipe()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ошибка создания пайпа:", err)
-			return
-		}
-		processes[i+1].Stdin = pipe
-	}
-
-	// Настройка последнего процесса для вывода
-	processes[len(processes)-1].Stdout = os.Stdout
-
-	// Запуск всех процессов
-	for _, process := range processes {
-		err := process.Start()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ошибка запуска команды:", err)
-			return
-		}
-	}
-
-	// Ожидание завершения процессов
-	for _, process := range processes {
-		err := process.Wait()
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "Ошибка выполнения команды:", err)
-		}
-	}
+	cmd.Stdout = s.stdout
+	cmd.Stderr = s.stderr
+	return commandStatus(s.stderr, "Ошибка выполнения команды ps:", cmd.Run())
 }
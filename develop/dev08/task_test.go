@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"wb-tech-l2/develop/internal/shellparse"
+)
+
+// chdirTemp переходит во временный каталог до конца теста
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestRunRedirects(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("нет команды cat")
+	}
+	dir := chdirTemp(t)
+	if err := os.WriteFile("in.txt", []byte("from file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		file       string
+		want       string
+		wantStatus int
+	}{
+		{"builtin output", "echo a > out.txt", "out.txt", "a\n", 0},
+		{"append", "echo a > out.txt; echo b >> out.txt", "out.txt", "a\nb\n", 0},
+		{"truncate", "echo long line > out.txt; echo s > out.txt", "out.txt", "s\n", 0},
+		{"input", "cat < in.txt > out.txt", "out.txt", "from file\n", 0},
+		{"here-document", "cat <<EOF > out.txt\nline 1\n  line 2\nEOF\n", "out.txt", "line 1\n  line 2\n", 0},
+		{"builtin stderr", "cd 2> err.txt", "err.txt", "Ошибка: отсутствует аргумент для cd\n", 1},
+		{"both streams", "echo out &> all.txt; cd &>> all.txt", "all.txt", "out\nОшибка: отсутствует аргумент для cd\n", 1},
+		{"stderr to stdout", "cd > all.txt 2>&1", "all.txt", "Ошибка: отсутствует аргумент для cd\n", 1},
+		{"stdout to stderr", "echo e 2> err.txt >&2", "err.txt", "e\n", 0},
+		{"external stderr", "cat missing.txt 2> err.txt", "err.txt", "cat: missing.txt: No such file or directory\n", 1},
+		{"only redirect creates file", "> empty.txt", "empty.txt", "", 0},
+		{"pipeline of builtin and externals", "echo hi | cat | cat > out.txt", "out.txt", "hi\n", 0},
+		{"redirect in first stage", "cat < in.txt | cat > out.txt", "out.txt", "from file\n", 0},
+		{"redirect in middle stage", "echo lost | cat < in.txt | cat > out.txt", "out.txt", "from file\n", 0},
+		{"middle stage output to file", "echo mid | cat > out.txt | cat", "out.txt", "mid\n", 0},
+		{"here-document in pipeline", "cat <<EOF | cat > out.txt\ndoc\nEOF\n", "out.txt", "doc\n", 0},
+		{"failed redirect skips command", "echo a > out.txt; cat < missing.txt > out.txt", "out.txt", "a\n", 1},
+		{"failed redirect status", "cat < missing.txt || echo fallback > out.txt", "out.txt", "fallback\n", 0},
+		{"not found to stderr file", "no-such-command-here 2> err.txt", "err.txt", "Ошибка запуска команды: exec: \"no-such-command-here\": executable file not found in $PATH\n", 127},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := shellparse.Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status := runList(list); status != test.wantStatus {
+				t.Errorf("got status %d, want %d", status, test.wantStatus)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Errorf("got %q, want %q", data, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyRedirectsErrors(t *testing.T) {
+	chdirTemp(t)
+	base := streams{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	tests := []struct {
+		name     string
+		redirect shellparse.Redirect
+		wantErr  string
+	}{
+		{"missing input", shellparse.Redirect{Fd: 0, Op: "<", Target: "missing.txt"}, "open missing.txt: no such file or directory"},
+		{"input to stdout", shellparse.Redirect{Fd: 1, Op: "<", Target: "x"}, "1<: перенаправление ввода возможно только для дескриптора 0"},
+		{"unsupported descriptor", shellparse.Redirect{Fd: 3, Op: ">", Target: "x"}, "3>: перенаправление вывода возможно только для дескрипторов 1 и 2"},
+		{"bad duplicate target", shellparse.Redirect{Fd: 2, Op: ">&", Target: "5"}, "5: неверный дескриптор"},
+		{"bad duplicate source", shellparse.Redirect{Fd: 0, Op: ">&", Target: "1"}, "0>&: копирование возможно только для дескрипторов 1 и 2"},
+		{"missing directory", shellparse.Redirect{Fd: 1, Op: ">", Target: "no/such/dir.txt"}, "open no/such/dir.txt: no such file or directory"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, files, err := applyRedirects([]shellparse.Redirect{test.redirect}, base)
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("got error %v, want %q", err, test.wantErr)
+			}
+			if files != nil {
+				t.Errorf("got open files %v after error", files)
+			}
+		})
+	}
+}
+
+func TestRunCdInPipeline(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("нет команды cat")
+	}
+	dir := chdirTemp(t)
+	if err := os.Mkdir("sub", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("file.txt", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := filepath.EvalSymlinks(filepath.Join(dir, "sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		wantDir    string
+		wantStatus int
+	}{
+		{"first stage", "cd sub | cat", dir, 0},
+		{"last stage", "echo x | cd sub", dir, 0},
+		{"missing directory", "echo x | cd missing 2> err.txt", dir, 1},
+		{"not a directory", "echo x | cd file.txt 2> err.txt", dir, 1},
+		{"alone", "cd sub", sub, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			list, err := shellparse.Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status := runList(list)
+			wd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wd, _ = filepath.EvalSymlinks(wd); wd != test.wantDir {
+				t.Errorf("got directory %q, want %q", wd, test.wantDir)
+			}
+			if status != test.wantStatus {
+				t.Errorf("got status %d, want %d", status, test.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event повторяет структуру события, которую возвращает сервер календаря
+type Event struct {
+	ID        string    `json:"id"`                   // Уникальный идентификатор события
+	UserID    int       `json:"user_id"`              // ID пользователя
+	Title     string    `json:"title"`                // Название события
+	Date      time.Time `json:"date"`                 // Дата события
+	UpdatedAt time.Time `json:"updated_at,omitempty"` // Дата последнего обновления (если было)
+}
+
+// Client обращается к HTTP API сервера календаря
+type Client struct {
+	baseURL  string       // Адрес сервера, например http://localhost:8080
+	user     string       // Имя пользователя для Basic-авторизации (необязательно)
+	password string       // Пароль для Basic-авторизации (необязательно)
+	http     *http.Client // HTTP-клиент с таймаутом
+}
+
+// NewClient создает клиента для сервера по указанному адресу
+func NewClient(baseURL, user, password string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		user:     user,
+		password: password,
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+// CreateEvent создает событие пользователя на указанную дату
+func (c *Client) CreateEvent(userID int, title string, date time.Time) (string, error) {
+	return c.post("/create_event", url.Values{
+		"user_id": {strconv.Itoa(userID)},
+		"title":   {title},
+		"date":    {formatDate(date)},
+	})
+}
+
+// UpdateEvent меняет название и дату существующего события
+func (c *Client) UpdateEvent(id, title string, date time.Time) (string, error) {
+	return c.post("/update_event", url.Values{
+		"id":    {id},
+		"title": {title},
+		"date":  {formatDate(date)},
+	})
+}
+
+// DeleteEvent удаляет событие по ID
+func (c *Client) DeleteEvent(id string) (string, error) {
+	return c.post("/delete_event", url.Values{"id": {id}})
+}
+
+// EventsForDay возвращает события на конкретную дату
+func (c *Client) EventsForDay(date time.Time) ([]Event, error) {
+	return c.events("/events_for_day", url.Values{"date": {formatDate(date)}})
+}
+
+// EventsForWeek возвращает события на неделю, начиная с start
+func (c *Client) EventsForWeek(start time.Time) ([]Event, error) {
+	return c.events("/events_for_week", url.Values{"start": {formatDate(start)}})
+}
+
+// EventsForMonth возвращает события на месяц, начиная с start
+func (c *Client) EventsForMonth(start time.Time) ([]Event, error) {
+	return c.events("/events_for_month", url.Values{"start": {formatDate(start)}})
+}
+
+// post отправляет форму на сервер и возвращает поле result из ответа
+func (c *Client) post(path string, form url.Values) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var result struct {
+		Result string `json:"result"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return "", err
+	}
+	return result.Result, nil
+}
+
+// events выполняет GET-запрос и декодирует список событий
+func (c *Client) events(path string, query url.Values) ([]Event, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	if err := c.do(req, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// do выполняет запрос, проверяет статус и декодирует JSON-ответ в out
+func (c *Client) do(req *http.Request, out interface{}) error {
+	if c.user != "" || c.password != "" {
+		req.SetBasicAuth(c.user, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("could not read response: %v", err)
+	}
+
+	// Сервер сообщает об ошибках через поле error и код, отличный от 200
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+			return fmt.Errorf("server error (%d): %s", resp.StatusCode, apiErr.Error)
+		}
+		return fmt.Errorf("server error (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("could not decode response: %v", err)
+	}
+	return nil
+}
+
+// formatDate форматирует дату в формате, который ожидает сервер (YYYY-MM-DD)
+func formatDate(date time.Time) string {
+	return date.Format("2006-01-02")
+}
+
+// parseDate принимает дату в формате YYYY-MM-DD или RFC 3339
+func parseDate(dateStr string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", dateStr); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date, expected YYYY-MM-DD: " + dateStr)
+	}
+	return date, nil
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Config хранит настройки подключения к серверу календаря
+type Config struct {
+	Server   string `json:"server"`   // Адрес сервера
+	User     string `json:"user"`     // Имя пользователя
+	Password string `json:"password"` // Пароль
+	Timeout  string `json:"timeout"`  // Таймаут запросов, например "10s"
+}
+
+// Переменные окружения, переопределяющие значения из файла конфигурации
+const (
+	envConfig   = "CALENDARCTL_CONFIG"
+	envServer   = "CALENDAR_SERVER"
+	envUser     = "CALENDAR_USER"
+	envPassword = "CALENDAR_PASSWORD"
+)
+
+// Значения по умолчанию для полей конфигурации, не заданных или пустых
+const (
+	defaultServer  = "http://localhost:8080"
+	defaultTimeout = "10s"
+)
+
+const usage = `Usage: calendarctl [-config FILE] [-server URL] [-json] <command> [flags]
+
+Commands:
+  create  -user ID -title TITLE -date YYYY-MM-DD   create an event
+  update  -id ID -title TITLE -date YYYY-MM-DD     update an event
+  delete  -id ID                                   delete an event
+  day     [-date YYYY-MM-DD]                       events for a day
+  week    [-start YYYY-MM-DD]                      events for a week
+  month   [-start YYYY-MM-DD]                      events for a month
+  import  [-file FILE]                             create events from a JSON array
+  export  [-period day|week|month] [-start YYYY-MM-DD] [-file FILE]
+                                                   write events as a JSON array
+
+Configuration is read from -config, $CALENDARCTL_CONFIG or ~/.calendarctl.json
+and can be overridden by $CALENDAR_SERVER, $CALENDAR_USER and $CALENDAR_PASSWORD.
+`
+
+// errUsage означает неверное использование команды (код выхода 2)
+var errUsage = errors.New("usage error")
+
+// app содержит общее состояние для выполнения подкоманд
+type app struct {
+	client *Client   // Клиент API сервера
+	asJSON bool      // Выводить результат в формате JSON
+	stdin  io.Reader // Источник данных для import
+	stdout io.Writer // Вывод результатов
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// run разбирает аргументы, выполняет подкоманду и возвращает код выхода
+func run(args []string) int {
+	global := flag.NewFlagSet("calendarctl", flag.ContinueOnError)
+	global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	configPath := global.String("config", "", "Path to the config file")
+	server := global.String("server", "", "Calendar server URL")
+	asJSON := global.Bool("json", false, "Print results as JSON")
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return 2
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "calendarctl:", err)
+		return 1
+	}
+	if *server != "" {
+		config.Server = *server
+	}
+
+	timeout, err := time.ParseDuration(config.Timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "calendarctl: invalid timeout:", err)
+		return 1
+	}
+
+	a := &app{
+		client: NewClient(config.Server, config.User, config.Password, timeout),
+		asJSON: *asJSON,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
+
+	if err := a.dispatch(global.Arg(0), global.Args()[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintln(os.Stderr, "calendarctl:", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig собирает конфигурацию: значения по умолчанию, файл, переменные окружения
+func loadConfig(path string) (Config, error) {
+	var config Config
+
+	// Явно указанный файл обязан существовать, файл по умолчанию — нет
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(envConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".calendarctl.json")
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, &config); err != nil {
+				return Config{}, fmt.Errorf("could not unmarshal config %s: %v", path, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return Config{}, fmt.Errorf("could not read config file: %v", err)
+		}
+	}
+
+	// Пустые значения в файле означают значения по умолчанию
+	if config.Server == "" {
+		config.Server = defaultServer
+	}
+	if config.Timeout == "" {
+		config.Timeout = defaultTimeout
+	}
+
+	if v := os.Getenv(envServer); v != "" {
+		config.Server = v
+	}
+	if v := os.Getenv(envUser); v != "" {
+		config.User = v
+	}
+	if v := os.Getenv(envPassword); v != "" {
+		config.Password = v
+	}
+	return config, nil
+}
+
+// dispatch вызывает обработчик подкоманды
+func (a *app) dispatch(name string, args []string) error {
+	switch name {
+	case "create":
+		return a.create(args)
+	case "update":
+		return a.update(args)
+	case "delete":
+		return a.delete(args)
+	case "day", "week", "month":
+		return a.list(name, args)
+	case "import":
+		return a.importEvents(args)
+	case "export":
+		return a.exportEvents(args)
+	default:
+		fmt.Fprintf(os.Stderr, "calendarctl: unknown command %q\n\n%s", name, usage)
+		return errUsage
+	}
+}
+
+// newFlagSet создает набор флагов подкоманды с общим флагом -json
+func (a *app) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("calendarctl "+name, flag.ContinueOnError)
+	fs.BoolVar(&a.asJSON, "json", a.asJSON, "Print results as JSON")
+	return fs
+}
+
+// parseFlags разбирает флаги подкоманды, ошибки разбора считаются ошибками использования
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "%s: unexpected arguments: %v\n", fs.Name(), fs.Args())
+		return errUsage
+	}
+	return nil
+}
+
+// requireFlags проверяет, что обязательные строковые флаги заданы
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	for _, name := range names {
+		if fs.Lookup(name).Value.String() == "" {
+			fmt.Fprintf(os.Stderr, "%s: missing required flag -%s\n", fs.Name(), name)
+			return errUsage
+		}
+	}
+	return nil
+}
+
+// create создает новое событие
+func (a *app) create(args []string) error {
+	fs := a.newFlagSet("create")
+	user := fs.String("user", "", "User ID")
+	title := fs.String("title", "", "Event title")
+	dateStr := fs.String("date", "", "Event date (YYYY-MM-DD)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "user", "title", "date"); err != nil {
+		return err
+	}
+
+	userID, err := strconv.Atoi(*user)
+	if err != nil {
+		return errors.New("invalid user ID: " + *user)
+	}
+	date, err := parseDate(*dateStr)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.client.CreateEvent(userID, *title, date)
+	if err != nil {
+		return err
+	}
+	return a.printResult(result)
+}
+
+// update обновляет название и дату события
+func (a *app) update(args []string) error {
+	fs := a.newFlagSet("update")
+	id := fs.String("id", "", "Event ID")
+	title := fs.String("title", "", "Event title")
+	dateStr := fs.String("date", "", "Event date (YYYY-MM-DD)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "id", "title", "date"); err != nil {
+		return err
+	}
+
+	date, err := parseDate(*dateStr)
+	if err != nil {
+		return err
+	}
+
+	result, err := a.client.UpdateEvent(*id, *title, date)
+	if err != nil {
+		return err
+	}
+	return a.printResult(result)
+}
+
+// delete удаляет событие
+func (a *app) delete(args []string) error {
+	fs := a.newFlagSet("delete")
+	id := fs.String("id", "", "Event ID")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := requireFlags(fs, "id"); err != nil {
+		return err
+	}
+
+	result, err := a.client.DeleteEvent(*id)
+	if err != nil {
+		return err
+	}
+	return a.printResult(result)
+}
+
+// list выводит события за день, неделю или месяц
+func (a *app) list(period string, args []string) error {
+	fs := a.newFlagSet(period)
+	// Для дня параметр называется date, для недели и месяца — start, как в API сервера
+	dateFlag := "start"
+	if period == "day" {
+		dateFlag = "date"
+	}
+	dateStr := fs.String(dateFlag, formatDate(time.Now()), "Date (YYYY-MM-DD)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	events, err := a.fetch(period, *dateStr)
+	if err != nil {
+		return err
+	}
+	if a.asJSON {
+		return writeJSON(a.stdout, events)
+	}
+	return writeAgenda(a.stdout, events)
+}
+
+// importEvents создает события из JSON-массива (формат совпадает с выводом export)
+func (a *app) importEvents(args []string) error {
+	fs := a.newFlagSet("import")
+	file := fs.String("file", "-", "JSON file with events (- for stdin)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	in := a.stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var events []Event
+	if err := json.NewDecoder(in).Decode(&events); err != nil {
+		return fmt.Errorf("could not decode events: %v", err)
+	}
+
+	// Ошибка одного события не прерывает импорт остальных
+	var failed int
+	for i, event := range events {
+		if _, err := a.client.CreateEvent(event.UserID, event.Title, event.Date); err != nil {
+			fmt.Fprintf(os.Stderr, "calendarctl: event #%d (%q): %v\n", i+1, event.Title, err)
+			failed++
+		}
+	}
+
+	summary := map[string]int{"imported": len(events) - failed, "failed": failed}
+	if a.asJSON {
+		if err := writeJSON(a.stdout, summary); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintf(a.stdout, "Imported %d of %d events\n", summary["imported"], len(events))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d events failed to import", failed)
+	}
+	return nil
+}
+
+// exportEvents выгружает события за период в виде JSON-массива
+func (a *app) exportEvents(args []string) error {
+	fs := a.newFlagSet("export")
+	period := fs.String("period", "month", "Period to export: day, week or month")
+	start := fs.String("start", formatDate(time.Now()), "First day of the period (YYYY-MM-DD)")
+	file := fs.String("file", "-", "Output file (- for stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	events, err := a.fetch(*period, *start)
+	if err != nil {
+		return err
+	}
+
+	if *file == "-" {
+		return writeJSON(a.stdout, events)
+	}
+	out, err := os.Create(*file)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(out, events); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fetch запрашивает события за период и сортирует их по дате
+func (a *app) fetch(period, dateStr string) ([]Event, error) {
+	date, err := parseDate(dateStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	switch period {
+	case "day":
+		events, err = a.client.EventsForDay(date)
+	case "week":
+		events, err = a.client.EventsForWeek(date)
+	case "month":
+		events, err = a.client.EventsForMonth(date)
+	default:
+		return nil, fmt.Errorf("unknown period %q, expected day, week or month", period)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Сервер хранит события в мапе, поэтому порядок ответа не определен
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].ID < events[j].ID
+	})
+	return events, nil
+}
+
+// printResult выводит результат изменяющей команды
+func (a *app) printResult(result string) error {
+	if a.asJSON {
+		return writeJSON(a.stdout, map[string]string{"result": result})
+	}
+	_, err := fmt.Fprintln(a.stdout, result)
+	return err
+}
+
+// writeJSON выводит значение в формате JSON с отступами
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeAgenda выводит события в виде таблицы-ежедневника
+func writeAgenda(w io.Writer, events []Event) error {
+	if len(events) == 0 {
+		_, err := fmt.Fprintln(w, "No events")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tDAY\tID\tUSER\tTITLE")
+	for _, event := range events {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n",
+			formatDate(event.Date), event.Date.Format("Mon"), event.ID, event.UserID, event.Title)
+	}
+	return tw.Flush()
+}
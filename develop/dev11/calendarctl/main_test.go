@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer поднимает фейковый сервер календаря, запоминающий последний запрос
+func newTestServer(t *testing.T, events []Event) (*httptest.Server, *http.Request) {
+	t.Helper()
+	last := &http.Request{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*last = *r
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/create_event", "/update_event":
+			if r.FormValue("title") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "missing parameter: title"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"result": "ok"})
+		case "/delete_event":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": "event not found"})
+		default:
+			json.NewEncoder(w).Encode(events)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, last
+}
+
+func newTestApp(srv *httptest.Server, stdin string) (*app, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	return &app{
+		client: NewClient(srv.URL+"/", "alice", "secret", time.Second),
+		stdin:  strings.NewReader(stdin),
+		stdout: out,
+	}, out
+}
+
+func TestCreateSendsForm(t *testing.T) {
+	srv, last := newTestServer(t, nil)
+	a, out := newTestApp(srv, "")
+
+	if err := a.dispatch("create", []string{"-user", "7", "-title", "Standup", "-date", "2024-03-01"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if last.Method != http.MethodPost || last.URL.Path != "/create_event" {
+		t.Errorf("unexpected request %s %s", last.Method, last.URL.Path)
+	}
+	if got := last.PostForm.Get("user_id") + "|" + last.PostForm.Get("date"); got != "7|2024-03-01" {
+		t.Errorf("unexpected form: %q", got)
+	}
+	if user, pass, ok := last.BasicAuth(); !ok || user != "alice" || pass != "secret" {
+		t.Errorf("basic auth not sent: %q %q %v", user, pass, ok)
+	}
+	if out.String() != "ok\n" {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestServerErrorIsReported(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	a, _ := newTestApp(srv, "")
+
+	err := a.dispatch("delete", []string{"-id", "42"})
+	if err == nil || !strings.Contains(err.Error(), "event not found") {
+		t.Fatalf("expected server error, got %v", err)
+	}
+}
+
+func TestMissingFlagIsUsageError(t *testing.T) {
+	srv, _ := newTestServer(t, nil)
+	a, _ := newTestApp(srv, "")
+
+	if err := a.dispatch("update", []string{"-id", "1"}); err != errUsage {
+		t.Fatalf("expected usage error, got %v", err)
+	}
+}
+
+func TestAgendaIsSortedByDate(t *testing.T) {
+	events := []Event{
+		{ID: "2-20240305", UserID: 2, Title: "Review", Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{ID: "1-20240301", UserID: 1, Title: "Planning", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	srv, last := newTestServer(t, events)
+	a, out := newTestApp(srv, "")
+
+	if err := a.dispatch("week", []string{"-start", "2024-03-01"}); err != nil {
+		t.Fatalf("week: %v", err)
+	}
+	if last.URL.Path != "/events_for_week" || last.URL.Query().Get("start") != "2024-03-01" {
+		t.Errorf("unexpected request %s", last.URL)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "DATE") ||
+		!strings.Contains(lines[1], "Planning") || !strings.Contains(lines[2], "Review") {
+		t.Errorf("unexpected agenda:\n%s", out.String())
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	events := []Event{
+		{ID: "1-20240301", UserID: 1, Title: "Planning", Date: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	srv, last := newTestServer(t, events)
+	a, out := newTestApp(srv, "")
+
+	if err := a.dispatch("export", []string{"-period", "day", "-start", "2024-03-01"}); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	b, out2 := newTestApp(srv, out.String())
+	if err := b.dispatch("import", nil); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if last.URL.Path != "/create_event" || last.PostForm.Get("title") != "Planning" || last.PostForm.Get("date") != "2024-03-01" {
+		t.Errorf("unexpected import request: %s %v", last.URL.Path, last.PostForm)
+	}
+	if out2.String() != "Imported 1 of 1 events\n" {
+		t.Errorf("unexpected output: %q", out2.String())
+	}
+}
+
+func TestLoadConfigEnvOverridesFile(t *testing.T) {
+	path := t.TempDir() + "/config.json"
+	writeFile(t, path, `{"server": "http://file:1", "user": "bob", "timeout": "3s"}`)
+	t.Setenv(envServer, "http://env:2")
+	t.Setenv(envUser, "")
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.Server != "http://env:2" || config.User != "bob" || config.Timeout != "3s" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	if _, err := loadConfig(path + ".missing"); err == nil {
+		t.Error("expected error for missing explicit config")
+	}
+}
+
+func TestLoadConfigEmptyValuesUseDefaults(t *testing.T) {
+	path := t.TempDir() + "/config.json"
+	writeFile(t, path, `{"server": "", "timeout": ""}`)
+	t.Setenv(envServer, "")
+
+	config, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.Server != defaultServer || config.Timeout != defaultTimeout {
+		t.Errorf("unexpected config: %+v", config)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,430 @@
+// Package shellparse разбирает командную строку по правилам POSIX shell
+// в два этапа: лексер делит строку на слова и операторы с учетом кавычек
+// и экранирования, парсер собирает из них дерево: список конвейеров,
+// связанных операторами ';', '&&' и '||'. Разбор общий для шелла dev08
+// и конвейеров texttool.
+//
+//	list     = pipeline { (";" | "&&" | "||") pipeline } [";"]
+//	pipeline = command { "|" command }
+//	command  = (WORD | redirect) { WORD | redirect }
+//	redirect = REDIRECT WORD
+package shellparse
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrIncomplete сообщает, что строка оборвалась посреди команды
+// (незакрытая кавычка, '|' или '&&' в конце); шелл дочитывает следующую строку
+var ErrIncomplete = errors.New("незавершенная команда")
+
+// tokenKind — тип лексемы
+type tokenKind int
+
+const (
+	tokenWord     tokenKind = iota // Слово: имя команды или аргумент
+	tokenPipe                      // |
+	tokenAnd                       // &&
+	tokenOr                        // ||
+	tokenSemi                      // ; или перевод строки
+	tokenRedirect                  // Перенаправление: <, >, >>, >&, &>, &>>, <<, <<-
+	tokenEOF                       // Конец ввода
+)
+
+// token — лексема командной строки
+type token struct {
+	kind  tokenKind
+	value string // Текст слова без кавычек или сам оператор
+	fd    int    // Номер дескриптора перед перенаправлением ("2>"), -1 — не указан
+	body  string // Текст here-документа для "<<"
+}
+
+// String возвращает лексему в виде для сообщений об ошибках
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "конец строки"
+	case tokenSemi:
+		if t.value == "\n" {
+			return "перевод строки"
+		}
+	}
+	return "`" + t.value + "`"
+}
+
+// lex делит строку на лексемы. Как в POSIX shell, в одинарных кавычках все
+// символы буквальные, в двойных обратная косая черта экранирует только
+// '$', '`', '"', '\' и перевод строки, а вне кавычек — любой символ.
+// Комментарий начинается с '#' в начале слова и длится до конца строки.
+// Тексты here-документов читаются со строк, следующих за командой.
+func lex(input string) ([]token, error) {
+	var tokens []token
+	var word strings.Builder
+	inWord := false    // Слово начато, даже если оно пустое: ''
+	quoted := false    // В слове были кавычки или экранирование
+	var heredocs []int // Лексемы "<<", тексты которых еще не прочитаны
+
+	// endWord завершает текущее слово
+	endWord := func() {
+		if inWord {
+			tokens = append(tokens, token{kind: tokenWord, value: word.String(), fd: -1})
+			word.Reset()
+			inWord, quoted = false, false
+		}
+	}
+	// operator завершает слово и добавляет оператор
+	operator := func(kind tokenKind, value string) {
+		endWord()
+		tokens = append(tokens, token{kind: kind, value: value, fd: -1})
+	}
+	// redirect добавляет перенаправление. Слово из одних цифр без кавычек
+	// прямо перед ним — номер дескриптора: "2>" перенаправляет stderr.
+	redirect := func(value string) {
+		fd := -1
+		if inWord && !quoted && isDigits(word.String()) {
+			fd, _ = strconv.Atoi(word.String())
+			word.Reset()
+			inWord = false
+		}
+		operator(tokenRedirect, value)
+		tokens[len(tokens)-1].fd = fd
+		if strings.HasPrefix(value, "<<") {
+			heredocs = append(heredocs, len(tokens)-1)
+		}
+	}
+	// readHeredocs читает тексты here-документов начиная с позиции from
+	// и возвращает позицию после них
+	readHeredocs := func(runes []rune, from int) (int, error) {
+		for _, index := range heredocs {
+			// Без слова-ограничителя синтаксическую ошибку сообщит парсер
+			if index+1 >= len(tokens) || tokens[index+1].kind != tokenWord {
+				continue
+			}
+			delimiter := tokens[index+1].value
+			stripTabs := tokens[index].value == "<<-"
+			var body strings.Builder
+			for {
+				if from >= len(runes) {
+					return 0, fmt.Errorf("%w: here-документ не завершен строкой %s", ErrIncomplete, delimiter)
+				}
+				end := from
+				for end < len(runes) && runes[end] != '\n' {
+					end++
+				}
+				line := string(runes[from:end])
+				from = end + 1
+				if stripTabs {
+					line = strings.TrimLeft(line, "\t")
+				}
+				if line == delimiter {
+					break
+				}
+				body.WriteString(line + "\n")
+			}
+			tokens[index].body = body.String()
+		}
+		heredocs = nil
+		return from, nil
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case r == ' ' || r == '\t':
+			endWord()
+		case r == '\n':
+			operator(tokenSemi, "\n")
+			if len(heredocs) > 0 {
+				end, err := readHeredocs(runes, i+1)
+				if err != nil {
+					return nil, err
+				}
+				i = end - 1
+			}
+		case r == ';':
+			operator(tokenSemi, ";")
+		case r == '|' && next == '|':
+			operator(tokenOr, "||")
+			i++
+		case r == '|':
+			operator(tokenPipe, "|")
+		case r == '&' && next == '&':
+			operator(tokenAnd, "&&")
+			i++
+		case r == '&' && next == '>':
+			endWord()
+			if i+2 < len(runes) && runes[i+2] == '>' {
+				redirect("&>>")
+				i++
+			} else {
+				redirect("&>")
+			}
+			i++
+		case r == '&':
+			return nil, errors.New("запуск в фоне (&) не поддерживается")
+		case r == '<' && next == '<':
+			if i+2 < len(runes) && runes[i+2] == '-' {
+				redirect("<<-")
+				i++
+			} else {
+				redirect("<<")
+			}
+			i++
+		case r == '<':
+			redirect("<")
+		case r == '>' && (next == '>' || next == '&'):
+			redirect(string([]rune{r, next}))
+			i++
+		case r == '>':
+			redirect(">")
+		case r == '#' && !inWord:
+			for i+1 < len(runes) && runes[i+1] != '\n' {
+				i++
+			}
+		case r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: нет закрывающей кавычки '", ErrIncomplete)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord, quoted, i = true, true, end
+		case r == '"':
+			inWord, quoted = true, true
+			for i++; ; i++ {
+				if i == len(runes) {
+					return nil, fmt.Errorf("%w: нет закрывающей кавычки \"", ErrIncomplete)
+				}
+				if runes[i] == '"' {
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					// Экранированный перевод строки — продолжение строки
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("%w: обратная косая черта в конце строки", ErrIncomplete)
+			}
+			i++
+			if runes[i] == '\n' {
+				continue
+			}
+			word.WriteRune(runes[i])
+			inWord, quoted = true, true
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endWord()
+	if len(heredocs) > 0 {
+		if _, err := readHeredocs(nil, 0); err != nil {
+			return nil, err
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, fd: -1}), nil
+}
+
+// isDigits сообщает, что s состоит только из цифр ASCII
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Redirect — перенаправление ввода-вывода команды
+type Redirect struct {
+	Fd     int    // Перенаправляемый дескриптор: 0 — stdin, 1 — stdout, 2 — stderr
+	Op     string // "<", "<<", ">", ">>", ">&" (копия дескриптора), "&>", "&>>" (stdout и stderr)
+	Target string // Имя файла, номер дескриптора для ">&" или ограничитель here-документа
+	Body   string // Текст here-документа для "<<"
+}
+
+// Command — простая команда: имя, аргументы и перенаправления
+type Command struct {
+	Args      []string
+	Redirects []Redirect
+}
+
+// Pipeline — команды, соединенные через '|'
+type Pipeline struct {
+	Commands []*Command
+}
+
+// Operator связывает конвейер в списке с предыдущим
+type Operator int
+
+const (
+	OpSeq Operator = iota // ';' или перевод строки: выполнить всегда
+	OpAnd                 // '&&': выполнить, если предыдущий завершился успешно
+	OpOr                  // '||': выполнить, если предыдущий завершился с ошибкой
+)
+
+// ListItem — конвейер в списке и оператор перед ним
+type ListItem struct {
+	Op       Operator
+	Pipeline *Pipeline
+}
+
+// List — список конвейеров, выполняемых по очереди
+type List struct {
+	Items []ListItem
+}
+
+// Parse разбирает командную строку в список конвейеров.
+// Пустая строка или строка из одного комментария дает пустой список.
+func Parse(input string) (*List, error) {
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.parseList()
+}
+
+// parser строит дерево команд из лексем
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// peek возвращает текущую лексему, не сдвигаясь
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next возвращает текущую лексему и переходит к следующей
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// skipNewlines пропускает переводы строк: после '|', '&&', '||'
+// и между командами они ничего не значат
+func (p *parser) skipNewlines() {
+	for t := p.peek(); t.kind == tokenSemi && t.value == "\n"; t = p.peek() {
+		p.next()
+	}
+}
+
+// unexpected возвращает ошибку о лексеме t. Конец строки там, где нужна
+// команда, — незавершенный ввод, а не синтаксическая ошибка.
+func unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return fmt.Errorf("%w: ожидается команда", ErrIncomplete)
+	}
+	return fmt.Errorf("синтаксическая ошибка рядом с %s", t)
+}
+
+// parseList разбирает список конвейеров до конца ввода
+func (p *parser) parseList() (*List, error) {
+	list := &List{}
+	op := OpSeq
+	for {
+		p.skipNewlines()
+		if op == OpSeq && p.peek().kind == tokenEOF {
+			return list, nil
+		}
+		pipeline, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, ListItem{Op: op, Pipeline: pipeline})
+
+		switch t := p.next(); t.kind {
+		case tokenEOF:
+			return list, nil
+		case tokenSemi:
+			op = OpSeq
+		case tokenAnd:
+			op = OpAnd
+		case tokenOr:
+			op = OpOr
+		default:
+			return nil, unexpected(t)
+		}
+	}
+}
+
+// parsePipeline разбирает команды, соединенные через '|'
+func (p *parser) parsePipeline() (*Pipeline, error) {
+	pipeline := &Pipeline{}
+	for {
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		pipeline.Commands = append(pipeline.Commands, cmd)
+		if p.peek().kind != tokenPipe {
+			return pipeline, nil
+		}
+		p.next()
+		p.skipNewlines()
+	}
+}
+
+// parseCommand разбирает простую команду: слова и перенаправления в любом порядке.
+// Команда может состоять из одних перенаправлений: "> file" создает пустой файл.
+func (p *parser) parseCommand() (*Command, error) {
+	cmd := &Command{}
+	for {
+		switch t := p.peek(); t.kind {
+		case tokenWord:
+			cmd.Args = append(cmd.Args, p.next().value)
+			continue
+		case tokenRedirect:
+			p.next()
+			target := p.peek()
+			if target.kind != tokenWord {
+				return nil, unexpected(target)
+			}
+			p.next()
+			cmd.Redirects = append(cmd.Redirects, newRedirect(t, target.value))
+			continue
+		}
+		break
+	}
+	if len(cmd.Args) == 0 && len(cmd.Redirects) == 0 {
+		return nil, unexpected(p.peek())
+	}
+	return cmd, nil
+}
+
+// newRedirect строит перенаправление из оператора t и его цели.
+// Без явного дескриптора ввод перенаправляет stdin, вывод — stdout.
+func newRedirect(t token, target string) Redirect {
+	r := Redirect{Fd: t.fd, Op: t.value, Target: target, Body: t.body}
+	if r.Op == "<<-" {
+		r.Op = "<<"
+	}
+	if r.Fd < 0 {
+		r.Fd = 1
+		if strings.HasPrefix(r.Op, "<") {
+			r.Fd = 0
+		}
+	}
+	return r
+}
@@ -0,0 +1,220 @@
+package shellparse
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// commands возвращает аргументы всех команд списка: конвейер — срез команд
+func commands(list *List) [][][]string {
+	var result [][][]string
+	for _, item := range list.Items {
+		var pipeline [][]string
+		for _, cmd := range item.Pipeline.Commands {
+			pipeline = append(pipeline, cmd.Args)
+		}
+		result = append(result, pipeline)
+	}
+	return result
+}
+
+// operators возвращает операторы перед конвейерами списка
+func operators(list *List) []Operator {
+	var result []Operator
+	for _, item := range list.Items {
+		result = append(result, item.Op)
+	}
+	return result
+}
+
+func TestParseWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"fields", "ls  -l\t/tmp", []string{"ls", "-l", "/tmp"}},
+		{"double quotes keep spaces and pipes", `echo "a | b"`, []string{"echo", "a | b"}},
+		{"single quotes are literal", `echo 'a "b" \n $x'`, []string{"echo", `a "b" \n $x`}},
+		{"file name with spaces", `cat my\ file.txt`, []string{"cat", "my file.txt"}},
+		{"escapes in double quotes", `echo "\"q\" \\ \$x \n"`, []string{"echo", `"q" \ $x \n`}},
+		{"escaped quote outside quotes", `echo \'a\"`, []string{"echo", `'a"`}},
+		{"empty quoted arguments", `echo '' ""`, []string{"echo", "", ""}},
+		{"adjacent parts join", `echo a'b c'"d"\ e`, []string{"echo", "ab cd e"}},
+		{"line continuation", "echo a\\\nb \"c\\\nd\"", []string{"echo", "ab", "cd"}},
+		{"quoted operators", `echo ';' "&&" \|\|`, []string{"echo", ";", "&&", "||"}},
+		{"comment", "echo a # b | c", []string{"echo", "a"}},
+		{"hash inside word", "echo a#b", []string{"echo", "a#b"}},
+		{"unicode", `echo "привет мир"`, []string{"echo", "привет мир"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := [][][]string{{test.want}}
+			if got := commands(list); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseLists(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][][]string
+		wantOps []Operator
+	}{
+		{"empty", "   ", nil, nil},
+		{"only comment", "# nothing", nil, nil},
+		{"pipeline", "ps aux|grep go | wc -l", [][][]string{{{"ps", "aux"}, {"grep", "go"}, {"wc", "-l"}}}, []Operator{OpSeq}},
+		{"sequence", "cd /tmp; pwd;", [][][]string{{{"cd", "/tmp"}}, {{"pwd"}}}, []Operator{OpSeq, OpSeq}},
+		{"and or", "test -d x && echo yes || echo no", [][][]string{{{"test", "-d", "x"}}, {{"echo", "yes"}}, {{"echo", "no"}}}, []Operator{OpSeq, OpAnd, OpOr}},
+		{"pipelines in list", "a | b && c | d", [][][]string{{{"a"}, {"b"}}, {{"c"}, {"d"}}}, []Operator{OpSeq, OpAnd}},
+		{"newlines separate commands", "a\n\nb\n", [][][]string{{{"a"}}, {{"b"}}}, []Operator{OpSeq, OpSeq}},
+		{"newline after operators", "a |\nb &&\n\nc", [][][]string{{{"a"}, {"b"}}, {{"c"}}}, []Operator{OpSeq, OpAnd}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := commands(list); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if got := operators(list); !reflect.DeepEqual(got, test.wantOps) {
+				t.Errorf("got operators %v, want %v", got, test.wantOps)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		incomplete bool
+		wantErr    string
+	}{
+		{"unterminated single quote", "echo 'abc", true, "незавершенная команда: нет закрывающей кавычки '"},
+		{"unterminated double quote", `echo "abc`, true, `незавершенная команда: нет закрывающей кавычки "`},
+		{"escaped closing quote", `echo "abc\"`, true, `незавершенная команда: нет закрывающей кавычки "`},
+		{"trailing backslash", `echo abc\`, true, "незавершенная команда: обратная косая черта в конце строки"},
+		{"trailing pipe", "ls |", true, "незавершенная команда: ожидается команда"},
+		{"trailing and", "ls &&", true, "незавершенная команда: ожидается команда"},
+		{"leading pipe", "| ls", false, "синтаксическая ошибка рядом с `|`"},
+		{"double pipe operator without command", "ls || || pwd", false, "синтаксическая ошибка рядом с `||`"},
+		{"empty pipeline stage", "ls | | wc", false, "синтаксическая ошибка рядом с `|`"},
+		{"leading semicolon", "; ls", false, "синтаксическая ошибка рядом с `;`"},
+		{"double semicolon", "ls;; pwd", false, "синтаксическая ошибка рядом с `;`"},
+		{"newline after pipe then semicolon", "ls |\n;", false, "синтаксическая ошибка рядом с `;`"},
+		{"background", "sleep 1 &", false, "запуск в фоне (&) не поддерживается"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.input)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != test.wantErr {
+				t.Errorf("got error %q, want %q", err, test.wantErr)
+			}
+			if errors.Is(err, ErrIncomplete) != test.incomplete {
+				t.Errorf("got incomplete %v, want %v", !test.incomplete, test.incomplete)
+			}
+		})
+	}
+}
+func TestParseRedirects(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantArgs []string
+		want     []Redirect
+	}{
+		{"output", "echo a > out.txt", []string{"echo", "a"}, []Redirect{{Fd: 1, Op: ">", Target: "out.txt"}}},
+		{"no spaces", "echo a>out.txt", []string{"echo", "a"}, []Redirect{{Fd: 1, Op: ">", Target: "out.txt"}}},
+		{"append and input", "sort <in.txt >>out.txt", []string{"sort"}, []Redirect{{Fd: 0, Op: "<", Target: "in.txt"}, {Fd: 1, Op: ">>", Target: "out.txt"}}},
+		{"stderr", "ls x 2> err.txt", []string{"ls", "x"}, []Redirect{{Fd: 2, Op: ">", Target: "err.txt"}}},
+		{"stderr to stdout", "ls x > all.txt 2>&1", []string{"ls", "x"}, []Redirect{{Fd: 1, Op: ">", Target: "all.txt"}, {Fd: 2, Op: ">&", Target: "1"}}},
+		{"stdout to stderr", "echo a >&2", []string{"echo", "a"}, []Redirect{{Fd: 1, Op: ">&", Target: "2"}}},
+		{"both streams", "make &> log.txt", []string{"make"}, []Redirect{{Fd: 1, Op: "&>", Target: "log.txt"}}},
+		{"both streams append", "make &>> log.txt", []string{"make"}, []Redirect{{Fd: 1, Op: "&>>", Target: "log.txt"}}},
+		{"redirect before command", "> out.txt echo a", []string{"echo", "a"}, []Redirect{{Fd: 1, Op: ">", Target: "out.txt"}}},
+		{"only redirect", "> empty.txt", nil, []Redirect{{Fd: 1, Op: ">", Target: "empty.txt"}}},
+		{"quoted digits are an argument", `echo "2"> out.txt`, []string{"echo", "2"}, []Redirect{{Fd: 1, Op: ">", Target: "out.txt"}}},
+		{"digits inside word are an argument", "echo a2> out.txt", []string{"echo", "a2"}, []Redirect{{Fd: 1, Op: ">", Target: "out.txt"}}},
+		{"quoted target", `echo a > "my file.txt"`, []string{"echo", "a"}, []Redirect{{Fd: 1, Op: ">", Target: "my file.txt"}}},
+		{"quoted operator", `echo '>' a`, []string{"echo", ">", "a"}, nil},
+		{"here-document", "cat <<EOF\nline 1\n  'line 2'\nEOF\n", []string{"cat"}, []Redirect{{Fd: 0, Op: "<<", Target: "EOF", Body: "line 1\n  'line 2'\n"}}},
+		{"empty here-document", "cat <<EOF\nEOF", []string{"cat"}, []Redirect{{Fd: 0, Op: "<<", Target: "EOF"}}},
+		{"here-document strips tabs", "cat <<-END\n\ta\n\t\tb\n\tEND\n", []string{"cat"}, []Redirect{{Fd: 0, Op: "<<", Target: "END", Body: "a\nb\n"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, err := Parse(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cmd := list.Items[0].Pipeline.Commands[0]
+			if !reflect.DeepEqual(cmd.Args, test.wantArgs) {
+				t.Errorf("got args %q, want %q", cmd.Args, test.wantArgs)
+			}
+			if !reflect.DeepEqual(cmd.Redirects, test.want) {
+				t.Errorf("got redirects %+v, want %+v", cmd.Redirects, test.want)
+			}
+		})
+	}
+}
+
+func TestParseHeredocsInList(t *testing.T) {
+	list, err := Parse("cat <<A | cat <<B; echo done\nfirst\nA\nsecond\nB\necho next\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][][]string{{{"cat"}, {"cat"}}, {{"echo", "done"}}, {{"echo", "next"}}}
+	if got := commands(list); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	pipeline := list.Items[0].Pipeline
+	if body := pipeline.Commands[0].Redirects[0].Body; body != "first\n" {
+		t.Errorf("got first body %q", body)
+	}
+	if body := pipeline.Commands[1].Redirects[0].Body; body != "second\n" {
+		t.Errorf("got second body %q", body)
+	}
+}
+
+func TestParseRedirectErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		incomplete bool
+		wantErr    string
+	}{
+		{"missing target", "echo a >", true, "незавершенная команда: ожидается команда"},
+		{"operator as target", "echo a > | cat", false, "синтаксическая ошибка рядом с `|`"},
+		{"unfinished here-document", "cat <<EOF\nline\n", true, "незавершенная команда: here-документ не завершен строкой EOF"},
+		{"here-document without body", "cat <<EOF", true, "незавершенная команда: here-документ не завершен строкой EOF"},
+		{"here-document without delimiter", "cat <<\n", false, "синтаксическая ошибка рядом с перевод строки"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.input)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if err.Error() != test.wantErr {
+				t.Errorf("got error %q, want %q", err, test.wantErr)
+			}
+			if errors.Is(err, ErrIncomplete) != test.incomplete {
+				t.Errorf("got incomplete %v, want %v", !test.incomplete, test.incomplete)
+			}
+		})
+	}
+}
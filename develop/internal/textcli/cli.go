@@ -0,0 +1,122 @@
+// Package textcli содержит общие для утилит sort, grep и cut соглашения:
+// коды завершения, формат сообщений об ошибках, открытие входных файлов
+// со стандартным вводом и перекодированием в UTF-8 и разбиение входа на записи.
+package textcli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Коды завершения всех утилит, как у POSIX grep
+const (
+	ExitOK    = 0 // Успешное завершение
+	ExitFalse = 1 // Отрицательный результат: нет совпадений, данные не отсортированы
+	ExitError = 2 // Ошибка в аргументах, при чтении или записи
+)
+
+// Command — утилита: выполняется с аргументами командной строки
+// (без имени программы) и возвращает код завершения
+type Command func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+// StdinName — имя файла, означающее стандартный ввод
+const StdinName = "-"
+
+// Fail выводит в stderr сообщение об ошибке утилиты tool
+// в виде "tool: сообщение" и возвращает ExitError
+func Fail(stderr io.Writer, tool string, a ...interface{}) int {
+	fmt.Fprintln(stderr, append([]interface{}{tool + ":"}, a...)...)
+	return ExitError
+}
+
+// DescribeError формирует сообщение об ошибке доступа к файлу name в виде
+// "name: причина", без повторения операции и пути из *os.PathError
+func DescribeError(name string, err error) string {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return fmt.Sprintf("%s: %v", name, err)
+}
+
+// fileError — ошибка доступа к файлу, описанная через DescribeError
+type fileError struct {
+	name string
+	err  error
+}
+
+// Error реализует error
+func (e *fileError) Error() string {
+	return DescribeError(e.name, e.err)
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *fileError) Unwrap() error {
+	return e.err
+}
+
+// LookupEncoding возвращает кодировку по имени из WHATWG Encoding Standard
+// (utf-8, windows-1251, koi8-r, utf-16le и т.д.). Для пустого имени и UTF-8
+// возвращается nil: такие данные не перекодируются.
+func LookupEncoding(name string) (encoding.Encoding, error) {
+	if name == "" {
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("неизвестная кодировка %q", name)
+	}
+	if enc == unicode.UTF8 {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// Input — открытый входной файл или стандартный ввод
+type Input struct {
+	io.Reader           // Данные в UTF-8
+	Source    io.Reader // Исходный поток: *os.File для файлов, stdin для "-"
+	closer    io.Closer
+}
+
+// Close закрывает файл; стандартный ввод не закрывается
+func (in *Input) Close() error {
+	if in.closer == nil {
+		return nil
+	}
+	return in.closer.Close()
+}
+
+// Open открывает файл name для чтения ("-" — стандартный ввод stdin) и, если
+// задана кодировка enc, перекодирует данные в UTF-8. Ошибка открытия
+// описывается так же, как в DescribeError.
+func Open(name string, stdin io.Reader, enc encoding.Encoding) (*Input, error) {
+	in := &Input{Source: stdin}
+	if name != StdinName {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, &fileError{name: name, err: err}
+		}
+		in.Source, in.closer = file, file
+	}
+
+	in.Reader = in.Source
+	if enc != nil {
+		in.Reader = enc.NewDecoder().Reader(in.Source)
+	}
+	return in, nil
+}
+
+// Args возвращает список файлов: без аргументов — стандартный ввод
+func Args(files []string) []string {
+	if len(files) == 0 {
+		return []string{StdinName}
+	}
+	return files
+}
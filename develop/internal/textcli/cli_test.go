@@ -0,0 +1,82 @@
+package textcli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupEncoding(t *testing.T) {
+	for _, name := range []string{"", "utf-8", "UTF8"} {
+		if enc, err := LookupEncoding(name); enc != nil || err != nil {
+			t.Errorf("%q: got %v, %v, want no encoding", name, enc, err)
+		}
+	}
+	if enc, err := LookupEncoding("cp1251"); enc == nil || err != nil {
+		t.Errorf("cp1251: got %v, %v", enc, err)
+	}
+	if _, err := LookupEncoding("no-such-encoding"); err == nil {
+		t.Error("expected error for unknown encoding")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cp1251.txt")
+	// "привет" в windows-1251
+	if err := os.WriteFile(path, []byte{0xef, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	enc, err := LookupEncoding("windows-1251")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		file  string
+		stdin string
+		want  string
+	}{
+		{"decoded file", path, "", "привет"},
+		{"stdin", StdinName, "\xef\xf0", "пр"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in, err := Open(test.file, strings.NewReader(test.stdin), enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer in.Close()
+			data, err := io.ReadAll(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.want {
+				t.Errorf("got %q, want %q", data, test.want)
+			}
+		})
+	}
+
+	missing := filepath.Join(dir, "missing.txt")
+	_, err = Open(missing, nil, nil)
+	if want := missing + ": no such file or directory"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+	if got := DescribeError(missing, err); got != missing+": no such file or directory" {
+		t.Errorf("DescribeError of an open error: got %q", got)
+	}
+}
+
+func TestFail(t *testing.T) {
+	var stderr bytes.Buffer
+	if code := Fail(&stderr, "cut", "ошибка", 1); code != ExitError {
+		t.Errorf("got code %d, want %d", code, ExitError)
+	}
+	if stderr.String() != "cut: ошибка 1\n" {
+		t.Errorf("got %q", stderr.String())
+	}
+}
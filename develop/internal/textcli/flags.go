@@ -0,0 +1,68 @@
+package textcli
+
+import (
+	"flag"
+	"strings"
+)
+
+// ExpandShortFlags приводит короткие флаги в стиле GNU к виду, понятному пакету flag:
+// "-k2,2n" превращается в "-k", "2,2n", а "-nr" — в "-n", "-r"
+func ExpandShortFlags(fs *flag.FlagSet, args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// Разбор флагов заканчивается на "--" или первом позиционном аргументе
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(result, args[i:]...)
+		}
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "--") || strings.Contains(name, "=") || fs.Lookup(name) != nil {
+			result = append(result, arg)
+			// Значение флага может идти отдельным аргументом
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				result = append(result, args[i])
+			}
+			continue
+		}
+
+		var expanded []string
+		for j, ch := range name {
+			f := fs.Lookup(string(ch))
+			if f == nil {
+				// Неизвестный флаг оставляем как есть, ошибку сообщит пакет flag
+				expanded = []string{arg}
+				break
+			}
+			expanded = append(expanded, "-"+string(ch))
+			if !isBoolFlag(f) {
+				if rest := name[j+1:]; rest != "" {
+					expanded = append(expanded, rest)
+				} else if i+1 < len(args) {
+					i++
+					expanded = append(expanded, args[i])
+				}
+				break
+			}
+		}
+		result = append(result, expanded...)
+	}
+	return result
+}
+
+// IsFlagSet сообщает, что флаг name явно указан в командной строке
+func IsFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// isBoolFlag сообщает, что флаг не требует значения
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,34 @@
+package textcli
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestExpandShortFlags(t *testing.T) {
+	fs := flag.NewFlagSet("sort", flag.ContinueOnError)
+	fs.String("k", "", "")
+	fs.Bool("n", false, "")
+	fs.Bool("r", false, "")
+	fs.String("output-delimiter", "", "")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"glued values and bool groups", []string{"-k2,2n", "-rn", "-k", "1", "-n", "file", "-k3"}, []string{"-k", "2,2n", "-r", "-n", "-k", "1", "-n", "file", "-k3"}},
+		{"value after group", []string{"-nk", "2"}, []string{"-n", "-k", "2"}},
+		{"long flags kept", []string{"--output-delimiter", "-x", "-n"}, []string{"--output-delimiter", "-x", "-n"}},
+		{"unknown flag kept", []string{"-nq"}, []string{"-nq"}},
+		{"double dash", []string{"--", "-rn"}, []string{"--", "-rn"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExpandShortFlags(fs, test.args); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package textcli
+
+import (
+	"bufio"
+	"bytes"
+)
+
+// ScanRecords возвращает функцию разбиения для bufio.Scanner на записи,
+// оканчивающиеся байтом terminator. В отличие от bufio.ScanLines, '\r' в конце
+// записи сохраняется: так вывод и смещения совпадают с содержимым входа.
+// Последняя запись может быть без terminator.
+func ScanRecords(terminator byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, terminator); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		// Запрашиваем больше данных
+		return 0, nil, nil
+	}
+}
+
+// ScanCSVRecords возвращает функцию разбиения для bufio.Scanner на записи CSV
+// с разделителем полей sep, оканчивающиеся байтом terminator. Как и по RFC 4180,
+// кавычка открывает значение только в начале поля, "" внутри кавычек означает
+// одну кавычку, а terminator внутри кавычек не завершает запись, поэтому
+// многострочные поля остаются в одной записи. '\r' в конце записи удаляется,
+// как в окончаниях CRLF. Пустой sep означает запятую. Функция хранит
+// состояние разбора, поэтому каждому сканеру нужна своя.
+func ScanCSVRecords(terminator byte, sep string) bufio.SplitFunc {
+	if sep == "" {
+		sep = ","
+	}
+	s := &csvScanner{terminator: terminator, sep: []byte(sep)}
+	s.reset()
+	return s.split
+}
+
+// csvScanner выделяет записи CSV. Разбор продолжается с места, где он
+// остановился при нехватке данных, поэтому длинная запись не просматривается
+// заново при каждом дочитывании.
+type csvScanner struct {
+	terminator byte
+	sep        []byte
+	pos        int  // Сколько байт текущей записи уже разобрано
+	fieldStart bool // pos — начало поля
+	inQuotes   bool // pos внутри значения в кавычках
+}
+
+// reset готовит разбор следующей записи
+func (s *csvScanner) reset() {
+	s.pos, s.fieldStart, s.inQuotes = 0, true, false
+}
+
+// split реализует bufio.SplitFunc
+func (s *csvScanner) split(data []byte, atEOF bool) (int, []byte, error) {
+	for s.pos < len(data) {
+		b := data[s.pos]
+		switch {
+		case s.inQuotes && b == '"':
+			if s.pos+1 == len(data) && !atEOF {
+				// Экранирована ли кавычка, станет ясно по следующему байту
+				return 0, nil, nil
+			}
+			if s.pos+1 < len(data) && data[s.pos+1] == '"' {
+				s.pos += 2
+				continue
+			}
+			s.inQuotes = false
+		case s.inQuotes:
+		case b == s.terminator:
+			advance, record := s.pos+1, data[:s.pos]
+			s.reset()
+			return advance, bytes.TrimSuffix(record, []byte("\r")), nil
+		case bytes.HasPrefix(data[s.pos:], s.sep):
+			s.pos += len(s.sep)
+			s.fieldStart = true
+			continue
+		case !atEOF && bytes.HasPrefix(s.sep, data[s.pos:]):
+			// Разделитель может закончиться в следующих данных
+			return 0, nil, nil
+		case b == '"' && s.fieldStart:
+			s.inQuotes = true
+		}
+		s.fieldStart = false
+		s.pos++
+	}
+	if atEOF && len(data) > 0 {
+		s.reset()
+		return len(data), bytes.TrimSuffix(data, []byte("\r")), nil
+	}
+	// Запрашиваем больше данных
+	return 0, nil, nil
+}
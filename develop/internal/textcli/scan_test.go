@@ -0,0 +1,71 @@
+package textcli
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestScanRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		split func() bufio.SplitFunc
+		input string
+		want  []string
+	}{
+		{"lines", lines('\n'), "a\nb\n", []string{"a", "b"}},
+		{"last record without terminator", lines('\n'), "a\nb", []string{"a", "b"}},
+		{"carriage return kept", lines('\n'), "a\r\nb\r\n", []string{"a\r", "b\r"}},
+		{"empty records", lines('\n'), "\n\na\n", []string{"", "", "a"}},
+		{"zero terminator", lines(0), "a\nb\x00c\x00", []string{"a\nb", "c"}},
+		{"quotes ignored", lines('\n'), "\"a\nb\"\n", []string{"\"a", "b\""}},
+		{"csv quoted newline", csv('\n', ","), "\"a\nb\",c\nd\n", []string{"\"a\nb\",c", "d"}},
+		{"csv quoted field after separator", csv('\n', ","), "x,\"a\nb\"\nd", []string{"x,\"a\nb\"", "d"}},
+		{"csv escaped quote", csv('\n', ","), "\"say \"\"hi\"\"\"\nx", []string{"\"say \"\"hi\"\"\"", "x"}},
+		{"csv escaped quote before newline", csv('\n', ","), "\"a\"\"\nb\"\nc", []string{"\"a\"\"\nb\"", "c"}},
+		{"csv stray quote in field", csv('\n', ","), "b,5\" disk\na,x\n", []string{"b,5\" disk", "a,x"}},
+		{"csv text after closing quote", csv('\n', ","), "\"a\"b\"\nc\n", []string{"\"a\"b\"", "c"}},
+		{"csv multi-character separator", csv('\n', "::"), "a::\"b\nc\"\nd:\"e\nf\n", []string{"a::\"b\nc\"", "d:\"e", "f"}},
+		{"csv default separator", csv('\n', ""), "a,\"b\nc\"\n", []string{"a,\"b\nc\""}},
+		{"csv carriage return removed", csv('\n', ","), "a,b\r\nc\r", []string{"a,b", "c"}},
+		{"csv unterminated quote", csv('\n', ","), "\"a\nb", []string{"\"a\nb"}},
+		{"csv zero terminator", csv(0, ","), "\"a\x00b\"\x00c", []string{"\"a\x00b\"", "c"}},
+		{"empty input", lines('\n'), "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Побайтовое чтение проверяет разбор записи, пришедшей по частям
+			readers := map[string]io.Reader{
+				"whole":    strings.NewReader(test.input),
+				"one byte": iotest.OneByteReader(strings.NewReader(test.input)),
+			}
+			for kind, r := range readers {
+				scanner := bufio.NewScanner(r)
+				scanner.Split(test.split())
+				var got []string
+				for scanner.Scan() {
+					got = append(got, scanner.Text())
+				}
+				if err := scanner.Err(); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Errorf("%s: got %q, want %q", kind, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+// lines возвращает конструктор ScanRecords для таблицы тестов
+func lines(terminator byte) func() bufio.SplitFunc {
+	return func() bufio.SplitFunc { return ScanRecords(terminator) }
+}
+
+// csv возвращает конструктор ScanCSVRecords для таблицы тестов
+func csv(terminator byte, sep string) func() bufio.SplitFunc {
+	return func() bufio.SplitFunc { return ScanCSVRecords(terminator, sep) }
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"wb-tech-l2/develop/internal/shellparse"
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// runPipe выполняет конвейер утилит из единственного аргумента, например
+// 'grep x | cut -f2 | sort -n'. Утилиты работают в отдельных горутинах одного
+// процесса и соединены через io.Pipe. Код завершения, как в shell, — код
+// последней утилиты.
+func runPipe(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		return textcli.Fail(stderr, "texttool", "pipe ожидает один аргумент — конвейер вида 'grep x | cut -f2 | sort -n'")
+	}
+	stages, err := parsePipeline(args[0])
+	if err != nil {
+		return textcli.Fail(stderr, "texttool", err)
+	}
+	cmds := make([]textcli.Command, len(stages))
+	for i, stage := range stages {
+		cmd, ok := commands[stage[0]]
+		if !ok {
+			return textcli.Fail(stderr, "texttool", "неизвестная команда в конвейере "+stage[0])
+		}
+		cmds[i] = cmd
+	}
+
+	// Ошибки всех утилит пишутся в общий stderr одновременно
+	stderr = &syncWriter{w: stderr}
+	codes := make([]int, len(stages))
+	var wg sync.WaitGroup
+	in := stdin
+	for i := range stages {
+		out := stdout
+		var next *io.PipeReader
+		var pw *io.PipeWriter
+		if i < len(stages)-1 {
+			next, pw = io.Pipe()
+			out = pw
+		}
+
+		wg.Add(1)
+		go func(i int, in io.Reader, out io.Writer, pw *io.PipeWriter) {
+			defer wg.Done()
+			codes[i] = cmds[i](stages[i][1:], in, out, stderr)
+			if pw != nil {
+				pw.Close()
+			}
+			// Утилита могла не дочитать вход (grep -m, sort с файлами),
+			// остаток вычитывается, чтобы предыдущая утилита не заблокировалась на записи
+			if i > 0 {
+				io.Copy(io.Discard, in)
+			}
+		}(i, in, out, pw)
+		in = next
+	}
+	wg.Wait()
+	return codes[len(codes)-1]
+}
+
+// parsePipeline разбирает конвейер на команды, а команды — на аргументы,
+// по правилам шелла dev08: кавычки, экранирование и '|' между командами.
+// Допускается ровно один конвейер без перенаправлений: утилиты соединяются
+// только друг с другом.
+func parsePipeline(s string) ([][]string, error) {
+	list, err := shellparse.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(list.Items) == 0:
+		return nil, errors.New("пустой конвейер")
+	case len(list.Items) > 1:
+		return nil, errors.New("в конвейере допустим только оператор '|'")
+	}
+
+	var stages [][]string
+	for _, cmd := range list.Items[0].Pipeline.Commands {
+		if len(cmd.Redirects) > 0 {
+			return nil, errors.New("перенаправления в конвейере не поддерживаются")
+		}
+		stages = append(stages, cmd.Args)
+	}
+	return stages, nil
+}
+
+// syncWriter защищает запись в w мьютексом
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write реализует io.Writer
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wb-tech-l2/develop/dev03/sortcmd"
+	"wb-tech-l2/develop/dev05/grepcmd"
+	"wb-tech-l2/develop/dev06/cutcmd"
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+// usage — справка по вызову texttool
+const usage = `Использование: texttool КОМАНДА [АРГУМЕНТ]...
+Команды:
+  sort  сортировка строк
+  grep  поиск строк по шаблону
+  cut   выбор полей, байтов или символов
+  pipe  конвейер команд в одном процессе: texttool pipe 'grep x | cut -f2 | sort -n'
+Утилиты можно вызывать и через ссылку на texttool с именем утилиты: sort, grep, cut.`
+
+// commands — утилиты, доступные в texttool и внутри конвейера pipe
+var commands = map[string]textcli.Command{
+	"sort": sortcmd.Run,
+	"grep": grepcmd.Run,
+	"cut":  cutcmd.Run,
+}
+
+func main() {
+	os.Exit(run(os.Args, os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run выполняет texttool с полной командной строкой argv, включая имя программы.
+// Если программа вызвана по имени утилиты (ссылка sort -> texttool, как в busybox),
+// все аргументы относятся к этой утилите, иначе утилиту выбирает первый аргумент.
+func run(argv []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(argv) > 0 {
+		if cmd, ok := commands[commandName(argv[0])]; ok {
+			return cmd(argv[1:], stdin, stdout, stderr)
+		}
+	}
+	if len(argv) < 2 {
+		fmt.Fprintln(stderr, usage)
+		return textcli.ExitError
+	}
+
+	name, args := argv[1], argv[2:]
+	switch name {
+	case "pipe":
+		return runPipe(args, stdin, stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprintln(stdout, usage)
+		return textcli.ExitOK
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		textcli.Fail(stderr, "texttool", "неизвестная команда "+name)
+		fmt.Fprintln(stderr, usage)
+		return textcli.ExitError
+	}
+	return cmd(args, stdin, stdout, stderr)
+}
+
+// commandName возвращает имя утилиты из пути к программе: "/usr/bin/sort" -> "sort"
+func commandName(program string) string {
+	return strings.TrimSuffix(filepath.Base(program), ".exe")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"wb-tech-l2/develop/internal/textcli"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  [][]string
+	}{
+		{"single command", "sort -n", [][]string{{"sort", "-n"}}},
+		{"pipeline", "grep x | cut -f2|sort -n", [][]string{{"grep", "x"}, {"cut", "-f2"}, {"sort", "-n"}}},
+		{"single quotes", `grep 'a | b' file`, [][]string{{"grep", "a | b", "file"}}},
+		{"double quotes", `cut -d "\"" -f "1\\2"`, [][]string{{"cut", "-d", `"`, "-f", `1\2`}}},
+		{"backslash in double quotes kept", `grep "\d"`, [][]string{{"grep", `\d`}}},
+		{"escaped pipe", `grep a\|b`, [][]string{{"grep", "a|b"}}},
+		{"empty quoted argument", `cut -d '' -f1`, [][]string{{"cut", "-d", "", "-f1"}}},
+		{"adjacent quotes join", `grep 'a'"b"c`, [][]string{{"grep", "abc"}}},
+		{"newline after pipe", "grep x |\n sort", [][]string{{"grep", "x"}, {"sort"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parsePipeline(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePipelineErrors(t *testing.T) {
+	for _, input := range []string{"", "sort |", "| sort", "grep x || sort", "grep 'x", `grep "x`, `grep x\`, "grep x; sort", "grep x && sort", "sort < in.txt", "# comment"} {
+		if _, err := parsePipeline(input); err == nil {
+			t.Errorf("%q: expected error", input)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(data, []byte("b:2\na:10\nc:1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		argv       []string
+		stdin      string
+		want       string
+		wantCode   int
+		wantStderr string
+	}{
+		{"subcommand", []string{"texttool", "sort", "-r"}, "a\nc\nb\n", "c\nb\na\n", textcli.ExitOK, ""},
+		{"argv[0] dispatch", []string{"/usr/local/bin/cut", "-d:", "-f2", data}, "", "2\n10\n1\n", textcli.ExitOK, ""},
+		{"argv[0] with exe suffix", []string{`grep.exe`, "-c", "a"}, "a\nb\na\n", "2\n", textcli.ExitOK, ""},
+		{"exit code of tool", []string{"texttool", "grep", "z"}, "a\n", "", textcli.ExitFalse, ""},
+		{"shared error format", []string{"texttool", "cut", "-f1", filepath.Join(dir, "missing")}, "", "", textcli.ExitError, "cut: " + filepath.Join(dir, "missing") + ": no such file or directory\n"},
+		{"unknown command", []string{"texttool", "awk"}, "", "", textcli.ExitError, "texttool: неизвестная команда awk\n" + usage + "\n"},
+		{"no command", []string{"texttool"}, "", "", textcli.ExitError, usage + "\n"},
+		{"help", []string{"texttool", "help"}, "", usage + "\n", textcli.ExitOK, ""},
+		{"pipe", []string{"texttool", "pipe", "grep -v c | cut -d: -f2 | sort -n"}, "b:2\nc:3\na:10\n", "2\n10\n", textcli.ExitOK, ""},
+		{"pipe reads files", []string{"texttool", "pipe", "cut -d: -f1 " + data + " | sort"}, "", "a\nb\nc\n", textcli.ExitOK, ""},
+		{"pipe exit code of last stage", []string{"texttool", "pipe", "sort | grep z"}, "a\n", "", textcli.ExitFalse, ""},
+		{"pipe stage error", []string{"texttool", "pipe", "cut -f0 | sort"}, "a\n", "", textcli.ExitOK, "cut: позиции нумеруются с 1\n"},
+		{"pipe unknown command", []string{"texttool", "pipe", "grep x | uniq"}, "", "", textcli.ExitError, "texttool: неизвестная команда в конвейере uniq\n"},
+		{"pipe syntax error", []string{"texttool", "pipe", "grep 'x"}, "", "", textcli.ExitError, "texttool: незавершенная команда: нет закрывающей кавычки '\n"},
+		{"pipe redirect", []string{"texttool", "pipe", "sort > out.txt"}, "", "", textcli.ExitError, "texttool: перенаправления в конвейере не поддерживаются\n"},
+		{"pipe without argument", []string{"texttool", "pipe"}, "", "", textcli.ExitError, "texttool: pipe ожидает один аргумент — конвейер вида 'grep x | cut -f2 | sort -n'\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(test.argv, strings.NewReader(test.stdin), &stdout, &stderr)
+			if stdout.String() != test.want {
+				t.Errorf("got %q, want %q", stdout.String(), test.want)
+			}
+			if code != test.wantCode {
+				t.Errorf("got exit code %d, want %d", code, test.wantCode)
+			}
+			if stderr.String() != test.wantStderr {
+				t.Errorf("got stderr %q, want %q", stderr.String(), test.wantStderr)
+			}
+		})
+	}
+}
+
+// TestPipeEarlyExit проверяет, что утилита, не дочитавшая вход,
+// не блокирует запись предыдущей утилиты
+func TestPipeEarlyExit(t *testing.T) {
+	input := strings.Repeat("line\n", 100000)
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"texttool", "pipe", "grep line | grep -m 1 line | cut -c1-2"}, strings.NewReader(input), &stdout, &stderr)
+	if code != textcli.ExitOK || stdout.String() != "li\n" || stderr.Len() != 0 {
+		t.Errorf("got code %d, output %q, stderr %q", code, stdout.String(), stderr.String())
+	}
+}
@@ -2,7 +2,12 @@ module wb-tech-l2
 
 go 1.23.1
 
-require github.com/beevik/ntp v1.4.3
+require (
+	github.com/beevik/ntp v1.4.3
+	github.com/klauspost/compress v1.18.0
+	github.com/ulikunitz/xz v0.5.12
+	golang.org/x/text v0.28.0
+)
 
 require (
 	golang.org/x/net v0.31.0 // indirect